@@ -0,0 +1,291 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// ApprovalStatus is decision of one approver
+type ApprovalStatus string
+
+const (
+	ApprovalWaiting     ApprovalStatus = "waiting"
+	ApprovalApproved    ApprovalStatus = "approved"
+	ApprovalDisapproved ApprovalStatus = "disapproved"
+)
+
+type Approval struct {
+	UserID string
+	Status ApprovalStatus
+
+	// display name of status, set in approval reports
+	StatusLabel string
+}
+
+// enum of approval statuses
+const approvalStatusEnumID = "approval-status"
+
+// ApprovalState is overall state of work item approvals
+type ApprovalState string
+
+const (
+	// all approvers approved
+	ApprovalStateApproved ApprovalState = "approved"
+	// some approvers did not decide yet and nobody disapproved
+	ApprovalStatePending ApprovalState = "pending"
+	// some approver disapproved
+	ApprovalStateRejected ApprovalState = "rejected"
+	// approval was not requested from anybody
+	ApprovalStateUnrequested ApprovalState = "unrequested"
+)
+
+type ApprovalSummary struct {
+	URI       string
+	ID        string
+	Title     string
+	State     ApprovalState
+	Approvals []Approval
+
+	// users who disapproved (rejected) or did not decide yet (pending)
+	Blocking []string
+}
+
+type ApprovalReport struct {
+	Items []ApprovalSummary
+}
+
+// AllowedApprovers returns users who can be asked to approve work item
+func (p *Polarion) AllowedApprovers(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]*model.User, error) {
+	req := tracker_ws.GetAllowedApprovers{
+		WorkitemURI: workItemURI,
+	}
+	resp, err := p.TrackerWS.GetAllowedApproversContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowed approvers of work item %s: %v", model.URI(workItemURI), err)
+	}
+
+	var users []*model.User
+	for _, u := range resp.GetAllowedApproversReturn {
+		if user := model.UserFromWS(u); user != nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// RequestApprovals adds users as approvers of work item, users must be allowed approvers.
+// Users are validated before any is added, users who are already approvers are skipped.
+func (p *Polarion) RequestApprovals(ctx context.Context, workItemURI *tracker_ws.SubterraURI, userIDs ...string) error {
+	allowed, err := p.AllowedApprovers(ctx, workItemURI)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]struct{}, len(allowed))
+	for _, user := range allowed {
+		known[user.ID] = struct{}{}
+	}
+	for _, id := range userIDs {
+		if _, ok := known[id]; !ok {
+			return fmt.Errorf("user '%s' is not allowed to approve work item %s%s", id, model.URI(workItemURI), suggestion(id, known))
+		}
+	}
+
+	approvals, err := p.Approvals(ctx, workItemURI)
+	if err != nil {
+		return err
+	}
+	for _, id := range userIDs {
+		if slices.ContainsFunc(approvals, func(a Approval) bool { return a.UserID == id }) {
+			continue
+		}
+		req := tracker_ws.AddApprovee{
+			WorkitemURI: workItemURI,
+			ApproveeId:  id,
+		}
+		if _, err := p.TrackerWS.AddApproveeContext(ctx, &req); err != nil {
+			return fmt.Errorf("failed to request approval of work item %s from '%s': %v", model.URI(workItemURI), id, err)
+		}
+		approvals = append(approvals, Approval{UserID: id, Status: ApprovalWaiting})
+	}
+	return nil
+}
+
+// RemoveApprovals removes users from approvers of work item
+func (p *Polarion) RemoveApprovals(ctx context.Context, workItemURI *tracker_ws.SubterraURI, userIDs ...string) error {
+	for _, id := range userIDs {
+		req := tracker_ws.RemoveApprovee{
+			WorkitemURI: workItemURI,
+			ApproveeId:  id,
+		}
+		if _, err := p.TrackerWS.RemoveApproveeContext(ctx, &req); err != nil {
+			return fmt.Errorf("failed to remove approver '%s' of work item %s: %v", id, model.URI(workItemURI), err)
+		}
+	}
+	return nil
+}
+
+// Approve records approval of work item by user
+func (p *Polarion) Approve(ctx context.Context, workItemURI *tracker_ws.SubterraURI, userID string) error {
+	return p.SetApprovalStatus(ctx, workItemURI, userID, ApprovalApproved)
+}
+
+// Disapprove records disapproval of work item by user
+func (p *Polarion) Disapprove(ctx context.Context, workItemURI *tracker_ws.SubterraURI, userID string) error {
+	return p.SetApprovalStatus(ctx, workItemURI, userID, ApprovalDisapproved)
+}
+
+// SetApprovalStatus records decision of approver, user must already be approver of work item
+func (p *Polarion) SetApprovalStatus(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	userID string,
+	status ApprovalStatus,
+) error {
+	switch status {
+	case ApprovalWaiting, ApprovalApproved, ApprovalDisapproved:
+	default:
+		return fmt.Errorf("unknown approval status '%s'", status)
+	}
+
+	approvals, err := p.Approvals(ctx, workItemURI)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(approvals, func(a Approval) bool { return a.UserID == userID }) {
+		return fmt.Errorf("user '%s' is not approver of work item %s", userID, model.URI(workItemURI))
+	}
+
+	req := tracker_ws.EditApproval{
+		WorkitemURI: workItemURI,
+		ApproveeId:  userID,
+		Status:      model.NewEnumID(string(status)),
+	}
+	if _, err := p.TrackerWS.EditApprovalContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to set approval of work item %s by '%s' to %s: %v", model.URI(workItemURI), userID, status, err)
+	}
+	return nil
+}
+
+// Approvals returns approvers of work item with their decisions
+func (p *Polarion) Approvals(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]Approval, error) {
+	wi, err := p.workItemFields(ctx, workItemURI, "approvals")
+	if err != nil {
+		return nil, err
+	}
+	return approvalsFromWS(wi.Approvals), nil
+}
+
+func approvalsFromWS(approvals *tracker_ws.ArrayOfApproval) []Approval {
+	if approvals == nil {
+		return nil
+	}
+
+	var result []Approval
+	for _, a := range approvals.Approval {
+		if a == nil {
+			continue
+		}
+		status := ApprovalStatus(model.EnumID(a.Status))
+		if status == "" {
+			status = ApprovalWaiting
+		}
+		result = append(result, Approval{UserID: userIDOf(a.User), Status: status})
+	}
+	return result
+}
+
+// SummarizeApprovals returns approval state of work items matching query
+func (p *Polarion) SummarizeApprovals(ctx context.Context, query string) (*ApprovalReport, error) {
+	req := tracker_ws.QueryWorkItems{
+		Query:  query,
+		Sort:   "id",
+		Fields: []string{"id", "title", "type", "approvals"},
+	}
+	resp, err := p.TrackerWS.QueryWorkItemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work items '%s': %v", query, err)
+	}
+
+	report := &ApprovalReport{}
+	for _, wi := range resp.QueryWorkItemsReturn {
+		if wi == nil {
+			continue
+		}
+		summary := approvalSummaryFromWS(wi)
+		labeler := p.EnumLabeler(uriProjectID(wi.Uri), model.EnumID(wi.Type_))
+		for i, a := range summary.Approvals {
+			summary.Approvals[i].StatusLabel = labeler.EnumLabel(ctx, approvalStatusEnumID, string(a.Status))
+		}
+		report.Items = append(report.Items, summary)
+	}
+	return report, nil
+}
+
+// summary without status labels
+func approvalSummaryFromWS(wi *tracker_ws.WorkItem) ApprovalSummary {
+	summary := ApprovalSummary{
+		URI:       model.URI(wi.Uri),
+		ID:        wi.Id,
+		Title:     wi.Title,
+		Approvals: approvalsFromWS(wi.Approvals),
+	}
+	summary.State, summary.Blocking = approvalState(summary.Approvals)
+	return summary
+}
+
+// disapprovals take precedence over waiting approvals,
+// statuses other than approved and disapproved are considered waiting
+func approvalState(approvals []Approval) (ApprovalState, []string) {
+	if len(approvals) == 0 {
+		return ApprovalStateUnrequested, nil
+	}
+
+	var rejecting, waiting []string
+	for _, a := range approvals {
+		switch a.Status {
+		case ApprovalApproved:
+		case ApprovalDisapproved:
+			rejecting = append(rejecting, a.UserID)
+		default:
+			waiting = append(waiting, a.UserID)
+		}
+	}
+	switch {
+	case len(rejecting) > 0:
+		return ApprovalStateRejected, rejecting
+	case len(waiting) > 0:
+		return ApprovalStatePending, waiting
+	}
+	return ApprovalStateApproved, nil
+}
+
+// WithState returns work items in given state
+func (r *ApprovalReport) WithState(state ApprovalState) []ApprovalSummary {
+	var items []ApprovalSummary
+	for _, item := range r.Items {
+		if item.State == state {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// AllApproved reports whether every work item is fully approved
+func (r *ApprovalReport) AllApproved() bool {
+	return len(r.WithState(ApprovalStateApproved)) == len(r.Items)
+}
+
+// Blocking returns work items blocking each user, i.e. waiting for user's decision or disapproved by user
+func (r *ApprovalReport) Blocking() map[string][]string {
+	blocking := map[string][]string{}
+	for _, item := range r.Items {
+		for _, user := range item.Blocking {
+			blocking[user] = append(blocking[user], item.ID)
+		}
+	}
+	return blocking
+}
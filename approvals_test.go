@@ -0,0 +1,122 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func TestApprovalState(t *testing.T) {
+	tests := []struct {
+		name         string
+		approvals    []Approval
+		want         ApprovalState
+		wantBlocking []string
+	}{
+		{"empty", nil, ApprovalStateUnrequested, nil},
+		{
+			"all approved",
+			[]Approval{{UserID: "alice", Status: ApprovalApproved}, {UserID: "bob", Status: ApprovalApproved}},
+			ApprovalStateApproved,
+			nil,
+		},
+		{
+			"waiting",
+			[]Approval{{UserID: "alice", Status: ApprovalApproved}, {UserID: "bob", Status: ApprovalWaiting}},
+			ApprovalStatePending,
+			[]string{"bob"},
+		},
+		{
+			"unknown status is waiting",
+			[]Approval{{UserID: "alice", Status: "custom"}},
+			ApprovalStatePending,
+			[]string{"alice"},
+		},
+		{
+			"any disapproved",
+			[]Approval{
+				{UserID: "alice", Status: ApprovalWaiting},
+				{UserID: "bob", Status: ApprovalDisapproved},
+				{UserID: "carol", Status: ApprovalApproved},
+			},
+			ApprovalStateRejected,
+			[]string{"bob"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, blocking := approvalState(tt.approvals)
+			if got != tt.want {
+				t.Errorf("approvalState() = %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(blocking, tt.wantBlocking) {
+				t.Errorf("blocking = %v, want %v", blocking, tt.wantBlocking)
+			}
+		})
+	}
+}
+
+func TestApprovalSummary(t *testing.T) {
+	approval := func(user, status string) string {
+		s := `<Approval><user uri="subterra:data-service:objects:/default/${User}` + user + `"/>`
+		if status != "" {
+			s += `<status><id>` + status + `</id></status>`
+		}
+		return s + `</Approval>`
+	}
+
+	tests := []struct {
+		name         string
+		approvals    string
+		want         ApprovalState
+		wantBlocking []string
+	}{
+		{"empty", "", ApprovalStateUnrequested, nil},
+		{"all approved", approval("alice", "approved") + approval("bob", "approved"), ApprovalStateApproved, nil},
+		{"any disapproved", approval("alice", "disapproved") + approval("bob", "waiting"), ApprovalStateRejected, []string{"alice"}},
+		// approval without status is waiting
+		{"waiting", approval("alice", "approved") + approval("bob", ""), ApprovalStatePending, []string{"bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wi tracker_ws.WorkItem
+			content := `<workItem uri="subterra:data-service:objects:/default/demo${WorkItem}DEMO-1"><id>DEMO-1</id>` +
+				`<approvals>` + tt.approvals + `</approvals></workItem>`
+			if err := xml.Unmarshal([]byte(content), &wi); err != nil {
+				t.Fatal(err)
+			}
+
+			summary := approvalSummaryFromWS(&wi)
+			if summary.ID != "DEMO-1" || summary.State != tt.want {
+				t.Errorf("summary = %+v, want state %s", summary, tt.want)
+			}
+			if !reflect.DeepEqual(summary.Blocking, tt.wantBlocking) {
+				t.Errorf("blocking = %v, want %v", summary.Blocking, tt.wantBlocking)
+			}
+		})
+	}
+}
+
+func TestApprovalReport(t *testing.T) {
+	report := &ApprovalReport{Items: []ApprovalSummary{
+		{ID: "DEMO-1", State: ApprovalStateApproved},
+		{ID: "DEMO-2", State: ApprovalStateRejected, Blocking: []string{"alice"}},
+		{ID: "DEMO-3", State: ApprovalStatePending, Blocking: []string{"alice", "bob"}},
+	}}
+	if report.AllApproved() {
+		t.Error("AllApproved() = true")
+	}
+	if got := report.WithState(ApprovalStatePending); len(got) != 1 || got[0].ID != "DEMO-3" {
+		t.Errorf("WithState(pending) = %+v", got)
+	}
+	want := map[string][]string{"alice": {"DEMO-2", "DEMO-3"}, "bob": {"DEMO-3"}}
+	if got := report.Blocking(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Blocking() = %v, want %v", got, want)
+	}
+
+	if !(&ApprovalReport{}).AllApproved() {
+		t.Error("AllApproved() of empty report = false")
+	}
+}
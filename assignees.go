@@ -0,0 +1,215 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+type AssignOptions struct {
+	// with no desired assignees, work item is auto-assigned by project rules
+	// instead of being left unassigned
+	AutoAssign bool
+}
+
+// AssigneeChanges are user IDs added to and removed from assignees of work item
+type AssigneeChanges struct {
+	Added   []string
+	Removed []string
+
+	// assignees were chosen by auto-assign, Added contains the chosen users
+	AutoAssigned bool
+}
+
+type AssignResult struct {
+	URI     string
+	ID      string
+	Changes *AssigneeChanges
+	Err     error
+}
+
+type AssignReport struct {
+	Results []AssignResult
+}
+
+// Failed returns results of work items which were not reassigned
+func (r *AssignReport) Failed() []AssignResult {
+	var failed []AssignResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// AllowedAssignees returns users who can be assigned to work item
+func (p *Polarion) AllowedAssignees(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]*model.User, error) {
+	req := tracker_ws.GetAllowedAssignees{
+		WorkitemURI: workItemURI,
+	}
+	resp, err := p.TrackerWS.GetAllowedAssigneesContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowed assignees of work item %s: %v", model.URI(workItemURI), err)
+	}
+
+	var users []*model.User
+	for _, u := range resp.GetAllowedAssigneesReturn {
+		if user := model.UserFromWS(u); user != nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// SetAssignees changes assignees of work item to given users, only the difference is applied.
+// New assignees must be allowed assignees of work item, they are validated before any change.
+// Users are added before others are removed, so work item is not left unassigned on failure.
+// If a change fails, changes applied before it are returned together with the error.
+func (p *Polarion) SetAssignees(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	userIDs []string,
+	opts *AssignOptions,
+) (*AssigneeChanges, error) {
+	if opts == nil {
+		opts = &AssignOptions{}
+	}
+
+	current, err := p.assignees(ctx, workItemURI)
+	if err != nil {
+		return nil, err
+	}
+
+	added, removed := diffStrings(current, userIDs)
+	if len(added) > 0 {
+		if err := p.validateAssignees(ctx, workItemURI, added); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := &AssigneeChanges{}
+	for _, id := range added {
+		req := tracker_ws.AddAssignee{
+			WorkitemURI: workItemURI,
+			AssigneeId:  id,
+		}
+		resp, err := p.TrackerWS.AddAssigneeContext(ctx, &req)
+		if err != nil {
+			return changes, fmt.Errorf("failed to assign '%s' to work item %s: %v", id, model.URI(workItemURI), err)
+		}
+		if !resp.AddAssigneeReturn {
+			return changes, fmt.Errorf("user '%s' was not assigned to work item %s", id, model.URI(workItemURI))
+		}
+		changes.Added = append(changes.Added, id)
+	}
+	for _, id := range removed {
+		req := tracker_ws.RemoveAssignee{
+			WorkitemURI: workItemURI,
+			AssigneeId:  id,
+		}
+		resp, err := p.TrackerWS.RemoveAssigneeContext(ctx, &req)
+		if err != nil {
+			return changes, fmt.Errorf("failed to unassign '%s' from work item %s: %v", id, model.URI(workItemURI), err)
+		}
+		if !resp.RemoveAssigneeReturn {
+			return changes, fmt.Errorf("user '%s' was not unassigned from work item %s", id, model.URI(workItemURI))
+		}
+		changes.Removed = append(changes.Removed, id)
+	}
+
+	if len(userIDs) == 0 && opts.AutoAssign {
+		req := tracker_ws.DoAutoassign{
+			WorkitemURI: workItemURI,
+		}
+		if _, err := p.TrackerWS.DoAutoassignContext(ctx, &req); err != nil {
+			return changes, fmt.Errorf("failed to auto-assign work item %s: %v", model.URI(workItemURI), err)
+		}
+		changes.AutoAssigned = true
+
+		assigned, err := p.assignees(ctx, workItemURI)
+		if err != nil {
+			return changes, err
+		}
+		// auto-assign may choose some of removed users again
+		for _, id := range assigned {
+			if i := slices.Index(changes.Removed, id); i >= 0 {
+				changes.Removed = slices.Delete(changes.Removed, i, i+1)
+				continue
+			}
+			changes.Added = append(changes.Added, id)
+		}
+	}
+	return changes, nil
+}
+
+// SetAssigneesForQuery sets assignees of all work items matching query.
+// Failure of one work item does not stop the others, it is reported in its result.
+func (p *Polarion) SetAssigneesForQuery(
+	ctx context.Context,
+	query string,
+	userIDs []string,
+	opts *AssignOptions,
+) (*AssignReport, error) {
+	req := tracker_ws.QueryWorkItems{
+		Query:  query,
+		Sort:   "id",
+		Fields: []string{"id"},
+	}
+	resp, err := p.TrackerWS.QueryWorkItemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work items '%s': %v", query, err)
+	}
+
+	report := &AssignReport{}
+	for _, wi := range resp.QueryWorkItemsReturn {
+		if wi == nil || wi.Uri == nil {
+			continue
+		}
+		result := AssignResult{URI: model.URI(wi.Uri), ID: wi.Id}
+		if result.Err = ctx.Err(); result.Err == nil {
+			result.Changes, result.Err = p.SetAssignees(ctx, wi.Uri, userIDs, opts)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// IDs of current assignees
+func (p *Polarion) assignees(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]string, error) {
+	wi, err := p.workItemFields(ctx, workItemURI, "assignee")
+	if err != nil {
+		return nil, err
+	}
+	if wi.Assignee == nil {
+		return nil, nil
+	}
+
+	var ids []string
+	for _, user := range wi.Assignee.User {
+		if id := userIDOf(user); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (p *Polarion) validateAssignees(ctx context.Context, workItemURI *tracker_ws.SubterraURI, userIDs []string) error {
+	allowed, err := p.AllowedAssignees(ctx, workItemURI)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]struct{}, len(allowed))
+	for _, user := range allowed {
+		known[user.ID] = struct{}{}
+	}
+	for _, id := range userIDs {
+		if _, ok := known[id]; !ok {
+			return fmt.Errorf("user '%s' can not be assigned to work item %s%s", id, model.URI(workItemURI), suggestion(id, known))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package polarion_wsdl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// attachment URI is URI of its work item followed by this marker and attachment ID
+const attachmentURIMarker = "${Attachment}"
+
+// Attachments returns attachments of work item, data is not included
+func (p *Polarion) Attachments(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]*tracker_ws.Attachment, error) {
+	wi, err := p.workItemFields(ctx, workItemURI, "attachments")
+	if err != nil {
+		return nil, err
+	}
+	if wi.Attachments == nil {
+		return nil, nil
+	}
+
+	var attachments []*tracker_ws.Attachment
+	for _, attachment := range wi.Attachments.Attachment {
+		if attachment != nil {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+// Attachment returns attachment metadata by its URI
+func (p *Polarion) Attachment(ctx context.Context, attachmentURI *tracker_ws.SubterraURI) (*tracker_ws.Attachment, error) {
+	workItemURI, id, err := splitAttachmentURI(attachmentURI)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := p.Attachments(ctx, workItemURI)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		if attachment.Id == id {
+			return attachment, nil
+		}
+	}
+	return nil, fmt.Errorf("attachment %s not found", model.URI(attachmentURI))
+}
+
+// DeleteAttachment deletes attachment by its URI
+func (p *Polarion) DeleteAttachment(ctx context.Context, attachmentURI *tracker_ws.SubterraURI) error {
+	workItemURI, id, err := splitAttachmentURI(attachmentURI)
+	if err != nil {
+		return err
+	}
+	req := tracker_ws.DeleteAttachment{
+		WorkitemURI: workItemURI,
+		Id:          id,
+	}
+	if _, err := p.TrackerWS.DeleteAttachmentContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to delete attachment %s: %v", model.URI(attachmentURI), err)
+	}
+	return nil
+}
+
+// UploadAttachment creates attachment of work item with content read from r.
+// Content is base64 encoded while it is sent, so file is never held in memory.
+// Created attachment is returned after its length is checked against uploaded size.
+func (p *Polarion) UploadAttachment(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	fileName string,
+	title string,
+	r io.Reader,
+) (*tracker_ws.Attachment, error) {
+	if workItemURI == nil || fileName == "" {
+		return nil, fmt.Errorf("work item URI and file name are required for attachment")
+	}
+
+	// servers whose createAttachment does not return ID of created attachment
+	// are handled by finding the attachment which was not there before
+	before, err := p.Attachments(ctx, workItemURI)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]struct{}{}
+	for _, attachment := range before {
+		existing[attachment.Id] = struct{}{}
+	}
+
+	res, size, err := p.streamAttachment(ctx, &tracker_ws.CreateAttachment{
+		WorkitemURI: workItemURI,
+		FileName:    fileName,
+		Title:       title,
+	}, "</createAttachment>", r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment %s to work item %s: %v", fileName, model.URI(workItemURI), err)
+	}
+	id, err := createdAttachmentID(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of attachment %s upload: %v", fileName, err)
+	}
+
+	after, err := p.Attachments(ctx, workItemURI)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range after {
+		if id != "" && attachment.Id != id {
+			continue
+		}
+		if _, ok := existing[attachment.Id]; id == "" && (ok || attachment.FileName != fileName) {
+			continue
+		}
+		if attachment.Length != size {
+			return attachment, fmt.Errorf(
+				"attachment %s was uploaded with %d bytes but has length %d", attachment.Id, size, attachment.Length,
+			)
+		}
+		return attachment, nil
+	}
+	return nil, fmt.Errorf("attachment %s was uploaded but not found in work item %s", fileName, model.URI(workItemURI))
+}
+
+// UpdateAttachment replaces content of attachment with content read from r,
+// which is streamed the same way as by UploadAttachment. Empty file name or title
+// keeps the current one. Updated attachment is returned after its length is checked.
+func (p *Polarion) UpdateAttachment(
+	ctx context.Context,
+	attachmentURI *tracker_ws.SubterraURI,
+	fileName string,
+	title string,
+	r io.Reader,
+) (*tracker_ws.Attachment, error) {
+	workItemURI, id, err := splitAttachmentURI(attachmentURI)
+	if err != nil {
+		return nil, err
+	}
+	current, err := p.Attachment(ctx, attachmentURI)
+	if err != nil {
+		return nil, err
+	}
+	if fileName == "" {
+		fileName = current.FileName
+	}
+	if title == "" {
+		title = current.Title
+	}
+
+	res, size, err := p.streamAttachment(ctx, &tracker_ws.UpdateAttachment{
+		WorkitemURI: workItemURI,
+		Id:          id,
+		FileName:    fileName,
+		Title:       title,
+	}, "</updateAttachment>", r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update attachment %s: %v", model.URI(attachmentURI), err)
+	}
+	res.Body.Close()
+
+	attachment, err := p.Attachment(ctx, attachmentURI)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.Length != size {
+		return attachment, fmt.Errorf(
+			"attachment %s was updated with %d bytes but has length %d", attachment.Id, size, attachment.Length,
+		)
+	}
+	return attachment, nil
+}
+
+// posts request with data read from r streamed into it in place of its omitted data element,
+// closingTag ends the request element. Returns response and number of bytes read from r.
+func (p *Polarion) streamAttachment(
+	ctx context.Context,
+	request any,
+	closingTag string,
+	r io.Reader,
+) (*http.Response, int64, error) {
+	envelope, err := p.sessionEnvelope(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	split := bytes.LastIndex(envelope, []byte(closingTag))
+	if split < 0 {
+		return nil, 0, fmt.Errorf("failed to build attachment request: %s not found", closingTag)
+	}
+
+	content := &countingReader{r: r}
+	body, writer := io.Pipe()
+	written := make(chan int64, 1)
+	go func() {
+		writer.CloseWithError(writeAttachmentData(writer, envelope[:split], envelope[split:], content))
+		written <- content.n
+	}()
+	res, err := p.postSOAP(ctx, p.trackerEndpoint, body)
+	// closed reader ends the writer, so its count is final once received
+	body.Close()
+	size := <-written
+	return res, size, err
+}
+
+// ID from createAttachment response, empty if server returns none
+func createdAttachmentID(r io.Reader) (string, error) {
+	body := bufio.NewReader(r)
+	found, err := findElement(body, "createAttachmentReturn")
+	if err != nil || !found {
+		return "", err
+	}
+	id, err := io.ReadAll(&charDataReader{r: body})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(id)), nil
+}
+
+func writeAttachmentData(w io.Writer, prefix, suffix []byte, data io.Reader) error {
+	if _, err := w.Write(prefix); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "<data>"); err != nil {
+		return err
+	}
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(encoder, data); err != nil {
+		return fmt.Errorf("failed to read attachment data: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "</data>"); err != nil {
+		return err
+	}
+	_, err := w.Write(suffix)
+	return err
+}
+
+// DownloadAttachment writes content of attachment to w while it is received,
+// request is authenticated with the web service session.
+// Returns number of written bytes, error is returned also if it differs from attachment length.
+func (p *Polarion) DownloadAttachment(ctx context.Context, attachmentURI *tracker_ws.SubterraURI, w io.Writer) (int64, error) {
+	attachment, err := p.Attachment(ctx, attachmentURI)
+	if err != nil {
+		return 0, err
+	}
+	workItemURI, id, err := splitAttachmentURI(attachmentURI)
+	if err != nil {
+		return 0, err
+	}
+
+	envelope, err := p.sessionEnvelope(&tracker_ws.GetAttachment{
+		WorkitemURI:  workItemURI,
+		AttachmentId: id,
+	})
+	if err != nil {
+		return 0, err
+	}
+	res, err := p.postSOAP(ctx, p.trackerEndpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return 0, fmt.Errorf("failed to download attachment %s: %v", model.URI(attachmentURI), err)
+	}
+	defer res.Body.Close()
+
+	body := bufio.NewReader(res.Body)
+	found, err := findElement(body, "getAttachmentReturn")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read attachment %s: %v", model.URI(attachmentURI), err)
+	}
+	var n int64
+	if found {
+		decoder := base64.NewDecoder(base64.StdEncoding, &charDataReader{r: body})
+		if n, err = io.Copy(w, decoder); err != nil {
+			return n, fmt.Errorf("failed to download attachment %s: %v", model.URI(attachmentURI), err)
+		}
+	}
+	if n != attachment.Length {
+		return n, fmt.Errorf(
+			"attachment %s was downloaded with %d bytes but has length %d", model.URI(attachmentURI), n, attachment.Length,
+		)
+	}
+	return n, nil
+}
+
+func splitAttachmentURI(attachmentURI *tracker_ws.SubterraURI) (*tracker_ws.SubterraURI, string, error) {
+	uri := model.URI(attachmentURI)
+	i := strings.LastIndex(uri, attachmentURIMarker)
+	if i < 0 || i+len(attachmentURIMarker) == len(uri) {
+		return nil, "", fmt.Errorf("'%s' is not attachment URI", uri)
+	}
+	return model.NewURI(uri[:i]), uri[i+len(attachmentURIMarker):], nil
+}
+
+// skips r past start tag with given local name, false if element is empty or not found
+func findElement(r *bufio.Reader, name string) (bool, error) {
+	for {
+		if _, err := r.ReadString('<'); err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+		tag, err := r.ReadString('>')
+		if err != nil {
+			return false, err
+		}
+		tag = strings.TrimSuffix(tag, ">")
+		selfClosing := strings.HasSuffix(tag, "/")
+		tagName := strings.TrimSuffix(strings.Fields(tag + " ")[0], "/")
+		if i := strings.Index(tagName, ":"); i >= 0 {
+			tagName = tagName[i+1:]
+		}
+		if tagName == name {
+			return !selfClosing, nil
+		}
+	}
+}
+
+// reads character data of element up to the next tag,
+// predefined XML entities and character references are decoded, other entities are rejected
+type charDataReader struct {
+	r    *bufio.Reader
+	done bool
+
+	// decoded entity not yet returned
+	pending []byte
+}
+
+func (c *charDataReader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		if len(c.pending) > 0 {
+			k := copy(b[n:], c.pending)
+			c.pending = c.pending[k:]
+			n += k
+			continue
+		}
+		if c.done {
+			break
+		}
+		ch, err := c.r.ReadByte()
+		if err == io.EOF {
+			return n, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return n, err
+		}
+		switch ch {
+		case '<':
+			c.done = true
+		case '&':
+			if c.pending, err = readEntity(c.r); err != nil {
+				return n, err
+			}
+		default:
+			b[n] = ch
+			n++
+		}
+	}
+	if n == 0 && c.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+var xmlEntities = map[string]string{"amp": "&", "lt": "<", "gt": ">", "quot": `"`, "apos": "'"}
+
+// longest entity name or character reference which is decoded
+const maxEntityLength = 10
+
+// decodes entity following '&'
+func readEntity(r *bufio.Reader) ([]byte, error) {
+	var name []byte
+	for len(name) <= maxEntityLength {
+		ch, err := r.ReadByte()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ch != ';' {
+			name = append(name, ch)
+			continue
+		}
+
+		if value, ok := xmlEntities[string(name)]; ok {
+			return []byte(value), nil
+		}
+		if digits, ok := strings.CutPrefix(string(name), "#"); ok {
+			base := 10
+			if hex, ok := strings.CutPrefix(digits, "x"); ok {
+				digits, base = hex, 16
+			}
+			code, err := strconv.ParseUint(digits, base, 32)
+			if err == nil && utf8.ValidRune(rune(code)) {
+				return utf8.AppendRune(nil, rune(code)), nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported entity '&%s;'", name)
+	}
+	return nil, fmt.Errorf("unterminated entity '&%s'", name)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,137 @@
+package polarion_wsdl
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func TestCreatedAttachmentID(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			"returned ID",
+			`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body>` +
+				`<createAttachmentResponse xmlns="http://ws.polarion.com/TrackerWebService-impl">` +
+				`<createAttachmentReturn>1-report.pdf</createAttachmentReturn>` +
+				`</createAttachmentResponse></soapenv:Body></soapenv:Envelope>`,
+			"1-report.pdf",
+		},
+		{
+			"void response",
+			`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body>` +
+				`<createAttachmentResponse xmlns="http://ws.polarion.com/TrackerWebService-impl"/>` +
+				`</soapenv:Body></soapenv:Envelope>`,
+			"",
+		},
+		{"empty body", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createdAttachmentID(strings.NewReader(tt.response))
+			if err != nil {
+				t.Fatalf("createdAttachmentID() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("createdAttachmentID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAttachmentData(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", "<a><data></data></a>"},
+		{"encoded", "hello", "<a><data>aGVsbG8=</data></a>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			content := &countingReader{r: strings.NewReader(tt.data)}
+			if err := writeAttachmentData(&b, []byte("<a>"), []byte("</a>"), content); err != nil {
+				t.Fatalf("writeAttachmentData() error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("writeAttachmentData() = %q, want %q", b.String(), tt.want)
+			}
+			if content.n != int64(len(tt.data)) {
+				t.Errorf("counted %d bytes, want %d", content.n, len(tt.data))
+			}
+		})
+	}
+}
+
+func TestCharDataReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", data: "aGVsbG8=</data>", want: "aGVsbG8="},
+		{name: "predefined entities", data: "a&amp;b &lt;&gt;&quot;&apos;</id>", want: `a&b <>"'`},
+		{name: "character references", data: "&#65;&#x42;&#x10D;</id>", want: "ABč"},
+		{name: "empty", data: "</id>", want: ""},
+		{name: "unknown entity", data: "a&nbsp;b</id>", wantErr: true},
+		{name: "invalid character reference", data: "&#xD800;</id>", wantErr: true},
+		{name: "unterminated entity", data: "a&amp b c d e f</id>", wantErr: true},
+		{name: "missing end tag", data: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// reading byte by byte returns decoded entities over several reads
+			r := &charDataReader{r: bufio.NewReader(strings.NewReader(tt.data))}
+			var got []byte
+			b := make([]byte, 1)
+			var err error
+			for {
+				var n int
+				n, err = r.Read(b)
+				got = append(got, b[:n]...)
+				if err != nil {
+					break
+				}
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("read error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentRequestClosingTag(t *testing.T) {
+	tests := []struct {
+		request    any
+		closingTag string
+	}{
+		{&tracker_ws.CreateAttachment{FileName: "a.txt"}, "</createAttachment>"},
+		{&tracker_ws.UpdateAttachment{Id: "1-a.txt"}, "</updateAttachment>"},
+	}
+	for _, tt := range tests {
+		p := &Polarion{}
+		envelope, err := p.sessionEnvelope(tt.request)
+		if err != nil {
+			t.Fatalf("sessionEnvelope() error = %v", err)
+		}
+		// data is streamed in place of omitted element
+		if strings.Contains(string(envelope), "<data>") || !strings.Contains(string(envelope), tt.closingTag) {
+			t.Errorf("envelope %s, want %s without data", envelope, tt.closingTag)
+		}
+	}
+}
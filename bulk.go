@@ -0,0 +1,383 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/session_ws"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+const defaultBulkBatchSize = 50
+
+type BulkStatus string
+
+const (
+	BulkCreated   BulkStatus = "created"
+	BulkUpdated   BulkStatus = "updated"
+	BulkUnchanged BulkStatus = "unchanged"
+	BulkFailed    BulkStatus = "failed"
+
+	// item has the same key as earlier item and was not written
+	BulkSkipped BulkStatus = "skipped"
+	// item was created or updated, but transaction was rolled back
+	BulkRolledBack BulkStatus = "rolled back"
+)
+
+type BulkOptions struct {
+	// project to search existing work items in and to create new ones
+	ProjectID string
+
+	// field identifying existing work items: "id" (default)
+	// or custom field such as "customFields.externalId"
+	KeyField string
+
+	// number of work items looked up with one query, 50 by default
+	BatchSize int
+
+	// run all writes in session transaction, which is rolled back if any item fails,
+	// created and updated items are then reported as BulkRolledBack
+	Transaction bool
+}
+
+type BulkResult struct {
+	// index of item in BulkUpsert input
+	Index  int
+	Key    string
+	URI    *tracker_ws.SubterraURI
+	Status BulkStatus
+	Err    error
+}
+
+type BulkReport struct {
+	Results []BulkResult
+}
+
+// Count returns number of results with given status
+func (r *BulkReport) Count(status BulkStatus) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *BulkReport) Failed() []BulkResult {
+	var failed []BulkResult
+	for _, result := range r.Results {
+		if result.Status == BulkFailed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// BulkUpsert creates or updates work items matched by key field.
+// Existing work items are updated only in fields set in the input item,
+// assignees are replaced and links are added if missing.
+// Items with the same key as an earlier item are skipped.
+// Error is returned only when the whole operation fails (e.g. transaction can not be started),
+// failures of single items are reported in BulkReport.
+//
+// Only lookups of existing items are batched (BatchSize keys per query), Polarion
+// has no operation writing several work items at once. Each new item costs two requests
+// (create and read-back of its updated timestamp), each changed existing item
+// an update of standard fields, one request per changed custom field, assignee
+// and link, and a read-back.
+func (p *Polarion) BulkUpsert(
+	ctx context.Context,
+	items []*WorkItem,
+	opts BulkOptions,
+) (report *BulkReport, err error) {
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required for bulk upsert")
+	}
+	if opts.KeyField == "" {
+		opts.KeyField = "id"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBulkBatchSize
+	}
+	if err := p.ValidateWorkItemFields(ctx, opts.ProjectID, []string{opts.KeyField}); err != nil {
+		return nil, err
+	}
+
+	report = &BulkReport{Results: make([]BulkResult, len(items))}
+	completed := false
+	if opts.Transaction {
+		if _, err := p.SessionWS.BeginTransactionContext(ctx, &session_ws.BeginTransaction{}); err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %v", err)
+		}
+		defer func() {
+			// transaction is ended also when ctx is canceled or upsert panics
+			rollback := !completed || len(report.Failed()) > 0
+			req := session_ws.EndTransaction{Rollback: rollback}
+			if _, endErr := p.SessionWS.EndTransactionContext(context.WithoutCancel(ctx), &req); endErr != nil {
+				err = fmt.Errorf("failed to end transaction (rollback: %v): %v", rollback, endErr)
+			}
+			if rollback {
+				report.rollBack()
+			}
+		}()
+	}
+
+	keys := bulkKeys(items, opts.KeyField, report)
+	var pending []int
+	for i := range items {
+		if report.Results[i].Status == "" {
+			pending = append(pending, i)
+		}
+	}
+	for start := 0; start < len(pending); start += opts.BatchSize {
+		end := min(start+opts.BatchSize, len(pending))
+		p.upsertBatch(ctx, items, keys, pending[start:end], opts, report)
+	}
+
+	completed = true
+	return report, nil
+}
+
+// marks written items as rolled back
+func (r *BulkReport) rollBack() {
+	for i := range r.Results {
+		switch r.Results[i].Status {
+		case BulkCreated, BulkUpdated:
+			r.Results[i].Status = BulkRolledBack
+			r.Results[i].Err = fmt.Errorf("rolled back because other items failed")
+		}
+	}
+}
+
+// key values of items, items without key fail and items with duplicate key are skipped
+func bulkKeys(items []*WorkItem, keyField string, report *BulkReport) []string {
+	keys := make([]string, len(items))
+	first := map[string]int{}
+	for i, item := range items {
+		result := &report.Results[i]
+		result.Index = i
+		if item == nil {
+			result.Status, result.Err = BulkFailed, fmt.Errorf("work item is nil")
+			continue
+		}
+
+		key, err := bulkKey(item, keyField)
+		if err == nil && key == "" {
+			err = fmt.Errorf("work item has no value for key field '%s'", keyField)
+		}
+		if err != nil {
+			result.Status, result.Err = BulkFailed, err
+			continue
+		}
+		keys[i], result.Key = key, key
+
+		if index, ok := first[key]; ok {
+			result.Status = BulkSkipped
+			result.Err = fmt.Errorf("key '%s' is duplicate of item %d", key, index)
+			continue
+		}
+		first[key] = i
+	}
+	return keys
+}
+
+// upserts items with given indexes
+func (p *Polarion) upsertBatch(
+	ctx context.Context,
+	items []*WorkItem,
+	keys []string,
+	batch []int,
+	opts BulkOptions,
+	report *BulkReport,
+) {
+	batchKeys := make([]string, 0, len(batch))
+	fields := map[string]struct{}{"id": {}, "updated": {}, opts.KeyField: {}}
+	for _, i := range batch {
+		batchKeys = append(batchKeys, keys[i])
+		for _, name := range setWorkItemFields(items[i]) {
+			fields[name] = struct{}{}
+		}
+	}
+
+	existing, err := p.findByKeys(ctx, opts, batchKeys, sortedKeys(fields))
+	for _, i := range batch {
+		result := &report.Results[i]
+		if err != nil {
+			result.Status, result.Err = BulkFailed, err
+			continue
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			result.Status, result.Err = BulkFailed, ctxErr
+			continue
+		}
+
+		matches := existing[keys[i]]
+		switch len(matches) {
+		case 0:
+			written, err := p.CreateWorkItem(ctx, opts.ProjectID, items[i])
+			if err != nil {
+				// URI is known if item was created but could not be read back
+				if written != nil {
+					result.URI = written.URI
+				}
+				result.Status, result.Err = BulkFailed, err
+				continue
+			}
+			result.URI, result.Status = written.URI, BulkCreated
+		case 1:
+			result.URI = matches[0].Uri
+			result.Status, result.Err = p.upsertExisting(ctx, matches[0], items[i])
+		default:
+			result.Status = BulkFailed
+			result.Err = fmt.Errorf("%d work items match key '%s'", len(matches), keys[i])
+		}
+	}
+}
+
+func (p *Polarion) upsertExisting(
+	ctx context.Context,
+	existing, item *WorkItem,
+) (BulkStatus, error) {
+	tracked, err := p.Track(existing)
+	if err != nil {
+		return BulkFailed, err
+	}
+
+	desired := model.WorkItemFromWS(&item.WorkItem)
+	for _, name := range setWorkItemFields(item) {
+		switch name {
+		case "customFields":
+			values, err := DecodeCustomFields(item.CustomFields)
+			if err != nil {
+				return BulkFailed, err
+			}
+			for key, value := range values {
+				tracked.Custom[key] = value
+			}
+		case "linkedWorkItems":
+			for _, link := range desired.Links {
+				if !containsLink(tracked.Item.Links, link) {
+					tracked.Item.Links = append(tracked.Item.Links, link)
+				}
+			}
+		case "assignee":
+			tracked.Item.Assignees = desired.Assignees
+		default:
+			if _, ok := readOnlyWorkItemFields[name]; ok {
+				continue
+			}
+			if !slices.Contains(trackedWorkItemFields, name) {
+				return BulkFailed, fmt.Errorf("work item field '%s' can not be upserted, use dedicated API", name)
+			}
+			modelField(tracked.Item, name).Set(modelField(desired, name))
+		}
+	}
+
+	changes, err := tracked.Changes()
+	if err != nil {
+		return BulkFailed, err
+	}
+	if changes.IsEmpty() {
+		return BulkUnchanged, nil
+	}
+	if _, err := tracked.Save(ctx); err != nil {
+		return BulkFailed, err
+	}
+	return BulkUpdated, nil
+}
+
+// finds work items of project with given key values, result is grouped by key
+func (p *Polarion) findByKeys(
+	ctx context.Context,
+	opts BulkOptions,
+	keys []string,
+	fields []string,
+) (map[string][]*WorkItem, error) {
+	var values []string
+	for _, key := range keys {
+		if key != "" {
+			values = append(values, luceneQuote(key))
+		}
+	}
+	found := map[string][]*WorkItem{}
+	if len(values) == 0 {
+		return found, nil
+	}
+
+	queryField := strings.TrimPrefix(opts.KeyField, customFieldsPrefix)
+	query := fmt.Sprintf(
+		"project.id:%s AND %s:(%s)",
+		luceneQuote(opts.ProjectID), queryField, strings.Join(values, " "),
+	)
+	items, err := p.queryWorkItems(ctx, query, "id", fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing work items: %v", err)
+	}
+
+	for _, wi := range items {
+		key, err := bulkKey(wi, opts.KeyField)
+		if err != nil {
+			return nil, err
+		}
+		found[key] = append(found[key], wi)
+	}
+	return found, nil
+}
+
+// string value of key field
+func bulkKey(wi *WorkItem, keyField string) (string, error) {
+	customKey, isCustom := strings.CutPrefix(keyField, customFieldsPrefix)
+	if !isCustom {
+		index, ok := workItemFieldIndex[keyField]
+		if !ok {
+			return "", fmt.Errorf("unknown key field '%s'", keyField)
+		}
+		value := reflect.ValueOf(wi.WorkItem).Field(index)
+		if value.Kind() != reflect.String {
+			return "", fmt.Errorf("key field '%s' is not a string field", keyField)
+		}
+		return value.String(), nil
+	}
+
+	raw, _ := customValue(wi.CustomFields, customKey)
+	value, err := DecodeCustomValue(raw)
+	if err != nil || value == nil {
+		return "", err
+	}
+	return fmt.Sprint(value), nil
+}
+
+// XML names of non-empty work item fields
+func setWorkItemFields(wi *WorkItem) []string {
+	value := reflect.ValueOf(wi.WorkItem)
+	var names []string
+	for name, index := range workItemFieldIndex {
+		if name != "uri" && name != "customFields" && !value.Field(index).IsZero() {
+			names = append(names, name)
+		}
+	}
+	if wi.CustomFields != nil {
+		names = append(names, "customFields")
+	}
+	slices.Sort(names)
+	return names
+}
+
+func containsLink(links []model.Link, link model.Link) bool {
+	for _, l := range links {
+		if l.URI == link.URI && l.Role == link.Role {
+			return true
+		}
+	}
+	return false
+}
+
+// quotes value for Lucene query
+func luceneQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
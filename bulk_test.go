@@ -0,0 +1,156 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func bulkItem(id string, custom ...*Custom) *WorkItem {
+	wi := &WorkItem{}
+	wi.Id = id
+	if len(custom) > 0 {
+		wi.CustomFields = &ArrayOfCustom{Custom: custom}
+	}
+	return wi
+}
+
+func TestBulkKeys(t *testing.T) {
+	externalID := func(value string) *Custom {
+		return &Custom{Key: "externalId", Value: &CustomValue{XSIType: "xsd:string", InnerXML: value}}
+	}
+
+	tests := []struct {
+		name       string
+		keyField   string
+		items      []*WorkItem
+		wantKeys   []string
+		wantStatus []BulkStatus
+	}{
+		{
+			"unique IDs",
+			"id",
+			[]*WorkItem{bulkItem("A-1"), bulkItem("A-2")},
+			[]string{"A-1", "A-2"},
+			[]BulkStatus{"", ""},
+		},
+		{
+			"duplicate IDs",
+			"id",
+			[]*WorkItem{bulkItem("A-1"), bulkItem("A-2"), bulkItem("A-1")},
+			[]string{"A-1", "A-2", "A-1"},
+			[]BulkStatus{"", "", BulkSkipped},
+		},
+		{
+			"nil and empty keys",
+			"id",
+			[]*WorkItem{nil, bulkItem("")},
+			[]string{"", ""},
+			[]BulkStatus{BulkFailed, BulkFailed},
+		},
+		{
+			"custom key",
+			"customFields.externalId",
+			[]*WorkItem{bulkItem("", externalID("x")), bulkItem("", externalID("x")), bulkItem("")},
+			[]string{"x", "x", ""},
+			[]BulkStatus{"", BulkSkipped, BulkFailed},
+		},
+		{
+			"non-string key field",
+			"unresolvable",
+			[]*WorkItem{bulkItem("A-1")},
+			[]string{""},
+			[]BulkStatus{BulkFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &BulkReport{Results: make([]BulkResult, len(tt.items))}
+			keys := bulkKeys(tt.items, tt.keyField, report)
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("keys %q, want %q", keys, tt.wantKeys)
+			}
+			for i, result := range report.Results {
+				if result.Index != i || result.Status != tt.wantStatus[i] {
+					t.Errorf("result %d = %+v, want status %q", i, result, tt.wantStatus[i])
+				}
+				if (result.Err != nil) != (tt.wantStatus[i] != "") {
+					t.Errorf("result %d error = %v", i, result.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkReportRollBack(t *testing.T) {
+	report := &BulkReport{Results: []BulkResult{
+		{Status: BulkCreated},
+		{Status: BulkUpdated},
+		{Status: BulkUnchanged},
+		{Status: BulkSkipped},
+		{Status: BulkFailed},
+	}}
+	report.rollBack()
+
+	want := []BulkStatus{BulkRolledBack, BulkRolledBack, BulkUnchanged, BulkSkipped, BulkFailed}
+	for i, result := range report.Results {
+		if result.Status != want[i] {
+			t.Errorf("result %d status %q, want %q", i, result.Status, want[i])
+		}
+	}
+	if report.Count(BulkRolledBack) != 2 || len(report.Failed()) != 1 {
+		t.Errorf("counts: rolled back %d, failed %d", report.Count(BulkRolledBack), len(report.Failed()))
+	}
+}
+
+func TestUpsertExisting(t *testing.T) {
+	existing := func() *WorkItem {
+		wi := bulkItem("A-1")
+		wi.Uri = model.NewURI("subterra:data-service:objects:/default/A${WorkItem}A-1")
+		wi.Title = "title"
+		return wi
+	}
+
+	tests := []struct {
+		name    string
+		item    func(wi *WorkItem)
+		want    BulkStatus
+		wantErr bool
+	}{
+		{"same title", func(wi *WorkItem) { wi.Title = "title" }, BulkUnchanged, false},
+		{"read-only field", func(wi *WorkItem) { wi.OutlineNumber = "1.2" }, BulkUnchanged, false},
+		{"module", func(wi *WorkItem) { wi.ModuleURI = model.NewURI("subterra:module") }, BulkFailed, true},
+		{"resolved on", func(wi *WorkItem) { wi.ResolvedOn = model.ToXSDDateTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) }, BulkFailed, true},
+		{"comments", func(wi *WorkItem) { wi.Comments = &tracker_ws.ArrayOfComment{} }, BulkFailed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := bulkItem("A-1")
+			tt.item(item)
+
+			status, err := (&Polarion{}).upsertExisting(context.Background(), existing(), item)
+			if status != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("upsertExisting = %q, %v, want %q error %v", status, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLuceneQuote(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"a", `"a"`},
+		{`a "b"`, `"a \"b\""`},
+		{`a\b`, `"a\\b"`},
+	}
+	for _, tt := range tests {
+		if got := luceneQuote(tt.in); got != tt.want {
+			t.Errorf("luceneQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+type CommentOptions struct {
+	Title string
+
+	// IDs of users who can see the comment, visible to everyone if empty.
+	// Visibility can be set only when comment is created.
+	VisibleTo []string
+
+	Tags []string
+}
+
+// PostComment adds top-level comment to work item
+func (p *Polarion) PostComment(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	text *model.Text,
+	opts *CommentOptions,
+) (*tracker_ws.SubterraURI, error) {
+	return p.createComment(ctx, workItemURI, text, opts)
+}
+
+// ReplyToComment adds reply to comment, replies can be nested
+func (p *Polarion) ReplyToComment(
+	ctx context.Context,
+	commentURI *tracker_ws.SubterraURI,
+	text *model.Text,
+	opts *CommentOptions,
+) (*tracker_ws.SubterraURI, error) {
+	return p.createComment(ctx, commentURI, text, opts)
+}
+
+func (p *Polarion) createComment(
+	ctx context.Context,
+	parentURI *tracker_ws.SubterraURI,
+	text *model.Text,
+	opts *CommentOptions,
+) (*tracker_ws.SubterraURI, error) {
+	if parentURI == nil || text == nil {
+		return nil, fmt.Errorf("parent URI and text are required for comment")
+	}
+	if opts == nil {
+		opts = &CommentOptions{}
+	}
+
+	req := tracker_ws.CreateCommentNew{
+		ParentURI: parentURI,
+		Title:     opts.Title,
+		Content:   text.ToWS(),
+		VisibleTo: opts.VisibleTo,
+	}
+	resp, err := p.TrackerWS.CreateCommentNewContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to comment %s: %v", model.URI(parentURI), err)
+	}
+
+	uri := resp.CreateCommentNewReturn
+	if len(opts.Tags) > 0 {
+		if err := p.SetCommentTags(ctx, uri, opts.Tags); err != nil {
+			return uri, err
+		}
+	}
+	return uri, nil
+}
+
+// ResolveComment resolves or unresolves comment, resolving top-level comment resolves its thread
+func (p *Polarion) ResolveComment(ctx context.Context, commentURI *tracker_ws.SubterraURI, resolved bool) error {
+	req := tracker_ws.SetResolvedComment{
+		CommentURI: commentURI,
+		Resolved:   resolved,
+	}
+	if _, err := p.TrackerWS.SetResolvedCommentContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to set comment %s resolved to %v: %v", model.URI(commentURI), resolved, err)
+	}
+	return nil
+}
+
+func (p *Polarion) IsCommentResolved(ctx context.Context, commentURI *tracker_ws.SubterraURI) (bool, error) {
+	req := tracker_ws.IsResolvedComment{
+		CommentURI: commentURI,
+	}
+	resp, err := p.TrackerWS.IsResolvedCommentContext(ctx, &req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if comment %s is resolved: %v", model.URI(commentURI), err)
+	}
+	return resp.IsResolvedCommentReturn, nil
+}
+
+// SetCommentTags replaces tags of comment
+func (p *Polarion) SetCommentTags(ctx context.Context, commentURI *tracker_ws.SubterraURI, tags []string) error {
+	req := tracker_ws.SetCommentTags{
+		CommentURI: commentURI,
+	}
+	for _, tag := range tags {
+		req.Tags = append(req.Tags, model.NewEnumID(tag))
+	}
+	if _, err := p.TrackerWS.SetCommentTagsContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to set tags of comment %s: %v", model.URI(commentURI), err)
+	}
+	return nil
+}
+
+// CommentThreads returns top-level comments of work item with nested replies, oldest first.
+// Replies to comments which are not visible are returned as top-level comments.
+func (p *Polarion) CommentThreads(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]*model.Comment, error) {
+	wi, err := p.workItemFields(ctx, workItemURI, "comments")
+	if err != nil {
+		return nil, err
+	}
+	if wi.Comments == nil {
+		return nil, nil
+	}
+	return commentThreads(wi.Comments.Comment), nil
+}
+
+// arranges comments into threads by their parent URIs, oldest first
+func commentThreads(wsComments []*tracker_ws.Comment) []*model.Comment {
+	var comments []*model.Comment
+	byURI := map[string]*model.Comment{}
+	for _, c := range wsComments {
+		if comment := model.CommentFromWS(c); comment != nil {
+			comments = append(comments, comment)
+			byURI[comment.URI] = comment
+		}
+	}
+	slices.SortStableFunc(comments, func(a, b *model.Comment) int {
+		return a.Created.Compare(b.Created)
+	})
+
+	var threads []*model.Comment
+	for _, comment := range comments {
+		parent, ok := byURI[comment.ParentURI]
+		if comment.ParentURI == "" || !ok {
+			threads = append(threads, comment)
+			continue
+		}
+		parent.Replies = append(parent.Replies, comment)
+	}
+	return threads
+}
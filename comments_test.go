@@ -0,0 +1,94 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+const commentURIPrefix = "subterra:data-service:objects:/default/demo${Comment}DEMO-1/"
+
+// comment as received in work item, parent is omitted if empty
+func commentXML(id, parent, created string) string {
+	s := `<Comment uri="` + commentURIPrefix + id + `"><id>` + id + `</id><created>` + created + `</created>`
+	if parent != "" {
+		s += `<parentCommentURI>` + commentURIPrefix + parent + `</parentCommentURI>`
+	}
+	return s + `</Comment>`
+}
+
+// threads written as IDs with replies in parentheses, e.g. "1(2 3(4)) 5"
+func threadIDs(comments []*model.Comment) string {
+	var ids []string
+	for _, c := range comments {
+		id := c.ID
+		if len(c.Replies) > 0 {
+			id += "(" + threadIDs(c.Replies) + ")"
+		}
+		ids = append(ids, id)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestCommentThreads(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+		want     string
+	}{
+		{"no comments", nil, ""},
+		{
+			"replies nested under parents",
+			[]string{
+				commentXML("1", "", "2024-01-01T10:00:00Z"),
+				commentXML("2", "1", "2024-01-01T11:00:00Z"),
+				commentXML("3", "2", "2024-01-01T12:00:00Z"),
+				commentXML("4", "1", "2024-01-01T13:00:00Z"),
+				commentXML("5", "", "2024-01-01T14:00:00Z"),
+			},
+			"1(2(3) 4) 5",
+		},
+		{
+			"threads and replies ordered by creation",
+			[]string{
+				commentXML("5", "", "2024-01-02T10:00:00Z"),
+				commentXML("4", "1", "2024-01-01T13:00:00Z"),
+				commentXML("2", "1", "2024-01-01T11:00:00Z"),
+				commentXML("1", "", "2024-01-01T10:00:00Z"),
+			},
+			"1(2 4) 5",
+		},
+		{
+			"reply to comment which is not visible is top-level",
+			[]string{
+				commentXML("1", "", "2024-01-01T10:00:00Z"),
+				commentXML("3", "2", "2024-01-01T12:00:00Z"),
+				commentXML("4", "3", "2024-01-01T13:00:00Z"),
+			},
+			"1 3(4)",
+		},
+		{
+			"same creation time keeps received order",
+			[]string{
+				commentXML("b", "", "2024-01-01T10:00:00Z"),
+				commentXML("a", "", "2024-01-01T10:00:00Z"),
+			},
+			"b a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var comments tracker_ws.ArrayOfComment
+			content := "<comments>" + strings.Join(tt.comments, "") + "</comments>"
+			if err := xml.Unmarshal([]byte(content), &comments); err != nil {
+				t.Fatalf("unmarshal comments: %v", err)
+			}
+			if got := threadIDs(commentThreads(comments.Comment)); got != tt.want {
+				t.Errorf("commentThreads() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
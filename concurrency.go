@@ -0,0 +1,213 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// ErrConflict is returned (wrapped in *ConflictError) when work item
+// was changed by someone else since it was read
+var ErrConflict = errors.New("work item was changed concurrently")
+
+// default number of merge retries when ConflictOptions.MaxRetries is not set
+const defaultConflictRetries = 3
+
+type ConflictError struct {
+	URI      string
+	Expected Expectation
+	Actual   Expectation
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf(
+		"%v: %s expected updated %v revision '%s', actual updated %v revision '%s'",
+		ErrConflict, e.URI,
+		e.Expected.Updated, e.Expected.Revision,
+		e.Actual.Updated, e.Actual.Revision,
+	)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// Expectation is state of work item the write is based on,
+// only non-empty values are checked
+type Expectation struct {
+	Updated  time.Time
+	Revision string
+}
+
+// MergeFunc is called on conflict with current state of work item
+// (fields from changes and "updated") and returns changes to retry with.
+// Custom fields of current work item keep their types, see DecodeCustomFields.
+type MergeFunc func(ctx context.Context, current *WorkItem, changes FieldMask) (FieldMask, error)
+
+type ConflictOptions struct {
+	// if nil conflicts are returned as errors
+	Merge      MergeFunc
+	MaxRetries int
+}
+
+// CheckUnchanged re-reads work item and returns *ConflictError if it differs from expectation.
+// Check is done right before the write, but Polarion can not make it atomic with the write.
+func (p *Polarion) CheckUnchanged(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	expected Expectation,
+) error {
+	if expected.Updated.IsZero() && expected.Revision == "" {
+		return fmt.Errorf("expected updated timestamp or revision is required")
+	}
+
+	var actual Expectation
+	if !expected.Updated.IsZero() {
+		wi, err := p.workItemFields(ctx, uri, "updated")
+		if err != nil {
+			return err
+		}
+		actual.Updated = model.FromXSDDateTime(wi.Updated)
+	}
+
+	if expected.Revision != "" {
+		revision, err := p.latestRevision(ctx, uri)
+		if err != nil {
+			return err
+		}
+		actual.Revision = revision
+	}
+
+	if (!expected.Updated.IsZero() && !expected.Updated.Equal(actual.Updated)) ||
+		(expected.Revision != "" && expected.Revision != actual.Revision) {
+		return &ConflictError{URI: model.URI(uri), Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// Polarion has no operation returning only the latest revision of work item,
+// getRevisions response is decoded keeping only its last entry instead of whole history
+type latestRevisionResponse struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl getRevisionsResponse"`
+
+	Revision string
+}
+
+func (r *latestRevisionResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "getRevisionsReturn" {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.DecodeElement(&r.Revision, &t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (p *Polarion) latestRevision(ctx context.Context, uri *tracker_ws.SubterraURI) (string, error) {
+	resp := &latestRevisionResponse{}
+	if err := p.TrackerClient.CallContext(ctx, "''", &tracker_ws.GetRevisions{In0: uri}, resp); err != nil {
+		return "", fmt.Errorf("failed to get revisions of %s: %v", model.URI(uri), err)
+	}
+	return resp.Revision, nil
+}
+
+// UpdateWorkItemIfUnchanged is UpdateWorkItem which fails with ErrConflict
+// if work item differs from expected state. With merge callback the update
+// is retried with merged changes based on current state of work item.
+func (p *Polarion) UpdateWorkItemIfUnchanged(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	expected Expectation,
+	changes FieldMask,
+	opts *ConflictOptions,
+) (*WriteResult, error) {
+	retries := 0
+	if opts != nil && opts.Merge != nil {
+		retries = opts.MaxRetries
+		if retries <= 0 {
+			retries = defaultConflictRetries
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := p.CheckUnchanged(ctx, uri, expected)
+		if err == nil {
+			return p.UpdateWorkItem(ctx, uri, changes)
+		}
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) || attempt >= retries {
+			return nil, err
+		}
+
+		current, err := p.currentState(ctx, uri, changes)
+		if err != nil {
+			return nil, err
+		}
+		if changes, err = opts.Merge(ctx, current, changes); err != nil {
+			return nil, fmt.Errorf("failed to merge changes of work item %s: %v", model.URI(uri), err)
+		}
+
+		// merged changes are based on current state, revision guard is kept
+		// with revision read before the current state
+		expected = Expectation{
+			Updated:  model.FromXSDDateTime(current.Updated),
+			Revision: conflict.Actual.Revision,
+		}
+		if expected.Updated.IsZero() {
+			return nil, fmt.Errorf("work item %s has no updated timestamp to merge against", model.URI(uri))
+		}
+	}
+}
+
+// reads fields of changes together with updated timestamp
+func (p *Polarion) currentState(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	changes FieldMask,
+) (*WorkItem, error) {
+	wi, err := p.getWorkItem(ctx, uri, append(sortedKeys(changes), "updated"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get work item %s: %v", model.URI(uri), err)
+	}
+	if wi == nil {
+		return nil, fmt.Errorf("work item %s %w", model.URI(uri), errWorkItemNotFound)
+	}
+	return wi, nil
+}
+
+// SaveIfUnchanged is Save which fails with ErrConflict if work item
+// was updated since it was fetched ("updated" field must be fetched)
+func (t *TrackedWorkItem) SaveIfUnchanged(ctx context.Context) (*WriteResult, error) {
+	original, _, err := t.original()
+	if err != nil {
+		return nil, err
+	}
+	if original.Updated.IsZero() {
+		return nil, fmt.Errorf("tracked work item has no updated timestamp, fetch it with 'updated' field")
+	}
+
+	uri := model.NewURI(original.URI)
+	if err := t.polarion.CheckUnchanged(ctx, uri, Expectation{Updated: original.Updated}); err != nil {
+		return nil, err
+	}
+	return t.Save(ctx)
+}
@@ -0,0 +1,60 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLatestRevisionResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want string
+	}{
+		{
+			"history",
+			`<getRevisionsResponse xmlns="http://ws.polarion.com/TrackerWebService-impl">
+				<getRevisionsReturn>10</getRevisionsReturn>
+				<getRevisionsReturn>15</getRevisionsReturn>
+				<getRevisionsReturn>42</getRevisionsReturn>
+			</getRevisionsResponse>`,
+			"42",
+		},
+		{
+			"unknown elements",
+			`<getRevisionsResponse xmlns="http://ws.polarion.com/TrackerWebService-impl">
+				<getRevisionsReturn>7</getRevisionsReturn>
+				<other><getRevisionsReturn>8</getRevisionsReturn></other>
+			</getRevisionsResponse>`,
+			"7",
+		},
+		{"no revisions", `<getRevisionsResponse xmlns="http://ws.polarion.com/TrackerWebService-impl"/>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp latestRevisionResponse
+			if err := xml.Unmarshal([]byte(tt.xml), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Revision != tt.want {
+				t.Errorf("revision = %q, want %q", resp.Revision, tt.want)
+			}
+		})
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	err := error(&ConflictError{
+		URI:      "subterra:data-service:objects:/default/demo${WorkItem}DEMO-1",
+		Expected: Expectation{Revision: "1"},
+		Actual:   Expectation{Updated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Revision: "2"},
+	})
+
+	var conflict *ConflictError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &conflict) || conflict.Actual.Revision != "2" {
+		t.Errorf("conflict error %v is not ErrConflict with actual revision", err)
+	}
+}
@@ -0,0 +1,315 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/test_ws"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+type CoverageStatus string
+
+const (
+	// all verifying test cases passed in their latest records
+	CoveragePassed CoverageStatus = "passed"
+	// latest record of some verifying test case failed
+	CoverageFailed CoverageStatus = "failed"
+	// some verifying test case was not executed or has other result (e.g. blocked)
+	CoverageIncomplete CoverageStatus = "incomplete"
+	// requirement has no verifying test case
+	CoverageUncovered CoverageStatus = "uncovered"
+)
+
+type CoverageOptions struct {
+	// query selecting requirements (rows of matrix)
+	RequirementQuery string
+
+	// link role between test case and requirement, e.g. "verifies",
+	// links in both directions are considered
+	Role string
+
+	// query on test records passed to searchTestRecords, e.g. "testRun.id:(R1 R2)",
+	// latest of the matching records of each test case is considered
+	TestRecordQuery string
+
+	// maximum number of test records read, defaultTestRecordLimit if zero,
+	// matrix is not built if more records match
+	TestRecordLimit int
+}
+
+const defaultTestRecordLimit = 10000
+
+type CoverageTest struct {
+	URI   string `json:"uri"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+
+	// result of latest test record, empty if test case was not executed
+	Result      string     `json:"result,omitempty"`
+	ResultLabel string     `json:"resultLabel,omitempty"`
+	Executed    *time.Time `json:"executed,omitempty"`
+}
+
+// enum of test record results
+const testResultEnumID = "testing/test-result"
+
+type CoverageRow struct {
+	URI    string         `json:"uri"`
+	ID     string         `json:"id"`
+	Title  string         `json:"title"`
+	Status CoverageStatus `json:"status"`
+	Tests  []CoverageTest `json:"tests"`
+}
+
+type CoverageMatrix struct {
+	Rows []CoverageRow `json:"rows"`
+}
+
+// BuildCoverageMatrix maps requirements to test cases linked with role
+// and to latest results of those test cases within test records matching query
+func (p *Polarion) BuildCoverageMatrix(ctx context.Context, opts CoverageOptions) (*CoverageMatrix, error) {
+	if opts.RequirementQuery == "" || opts.Role == "" || opts.TestRecordQuery == "" {
+		return nil, fmt.Errorf("requirement query, link role and test record query are required for coverage matrix")
+	}
+
+	req := tracker_ws.QueryWorkItems{
+		Query:  opts.RequirementQuery,
+		Sort:   "id",
+		Fields: []string{"id", "title", "linkedWorkItems"},
+	}
+	resp, err := p.TrackerWS.QueryWorkItemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query requirements '%s': %v", opts.RequirementQuery, err)
+	}
+
+	limit := opts.TestRecordLimit
+	if limit <= 0 {
+		limit = defaultTestRecordLimit
+	}
+	latest, err := p.latestTestRecords(ctx, opts.TestRecordQuery, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &CoverageMatrix{}
+	tests := map[string]*model.WorkItem{}
+	for _, wi := range resp.QueryWorkItemsReturn {
+		requirement := model.WorkItemFromWS(wi)
+		testURIs, err := p.verifyingTestCases(ctx, requirement, opts.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		row := CoverageRow{URI: requirement.URI, ID: requirement.ID, Title: requirement.Title}
+		for _, uri := range testURIs {
+			test, ok := tests[uri]
+			if !ok {
+				if test, err = p.workItemSummary(ctx, uri); err != nil {
+					return nil, err
+				}
+				tests[uri] = test
+			}
+
+			column := CoverageTest{URI: uri, ID: test.ID, Title: test.Title}
+			if record, ok := latest[uri]; ok {
+				if record.Result != nil && record.Result.Id != nil {
+					column.Result = *record.Result.Id
+					column.ResultLabel = p.EnumLabeler(uriProjectID(model.NewURI(uri)), "").
+						EnumLabel(ctx, testResultEnumID, column.Result)
+				}
+				if executed := model.FromXSDDateTime(record.Executed); !executed.IsZero() {
+					column.Executed = &executed
+				}
+			}
+			row.Tests = append(row.Tests, column)
+		}
+		row.Status = coverageStatus(row.Tests)
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	return matrix, nil
+}
+
+// URIs of test cases linked with requirement by role in any direction
+func (p *Polarion) verifyingTestCases(ctx context.Context, requirement *model.WorkItem, role string) ([]string, error) {
+	var uris []string
+	for _, link := range requirement.Links {
+		if link.Role == role && !slices.Contains(uris, link.URI) {
+			uris = append(uris, link.URI)
+		}
+	}
+
+	req := tracker_ws.GetBackLinkedWorkitems{WorkitemURI: model.NewURI(requirement.URI)}
+	resp, err := p.TrackerWS.GetBackLinkedWorkitemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get back links of work item %s: %v", requirement.URI, err)
+	}
+	for _, l := range resp.GetBackLinkedWorkitemsReturn {
+		if l == nil {
+			continue
+		}
+		link := model.LinkFromWS(l)
+		if link.Role == role && !slices.Contains(uris, link.URI) {
+			uris = append(uris, link.URI)
+		}
+	}
+	return uris, nil
+}
+
+// latest executed record of each test case among records matching query, keyed by test case URI.
+// searchTestRecords has no paging, so one more record than limit is requested
+// to detect truncated results.
+func (p *Polarion) latestTestRecords(ctx context.Context, query string, limit int) (map[string]*test_ws.TestRecord, error) {
+	req := test_ws.SearchTestRecords{
+		Query: query,
+		Limit: int32(limit + 1),
+	}
+	resp, err := p.TestWS.SearchTestRecordsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("test records search failed: %v", err)
+	}
+	if len(resp.SearchTestRecordsReturn) > limit {
+		return nil, fmt.Errorf("more than %d test records match '%s', narrow the query or raise the limit", limit, query)
+	}
+
+	latest := map[string]*test_ws.TestRecord{}
+	for _, record := range resp.SearchTestRecordsReturn {
+		if record == nil || record.TestCaseURI == nil {
+			continue
+		}
+		uri := string(*record.TestCaseURI)
+		executed := model.FromXSDDateTime(record.Executed)
+		if previous, ok := latest[uri]; !ok || executed.After(model.FromXSDDateTime(previous.Executed)) {
+			latest[uri] = record
+		}
+	}
+	return latest, nil
+}
+
+func coverageStatus(tests []CoverageTest) CoverageStatus {
+	if len(tests) == 0 {
+		return CoverageUncovered
+	}
+	status := CoveragePassed
+	for _, test := range tests {
+		switch test.Result {
+		case "passed":
+		case "failed":
+			return CoverageFailed
+		default:
+			status = CoverageIncomplete
+		}
+	}
+	return status
+}
+
+// RowsWithStatus returns rows with given status
+func (m *CoverageMatrix) RowsWithStatus(status CoverageStatus) []CoverageRow {
+	var rows []CoverageRow
+	for _, row := range m.Rows {
+		if row.Status == status {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (m *CoverageMatrix) Uncovered() []CoverageRow {
+	return m.RowsWithStatus(CoverageUncovered)
+}
+
+func (m *CoverageMatrix) Failed() []CoverageRow {
+	return m.RowsWithStatus(CoverageFailed)
+}
+
+func (m *CoverageMatrix) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// CSV writes one line per requirement and test case pair,
+// uncovered requirements have empty test case columns
+func (m *CoverageMatrix) CSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{
+		"Requirement", "Requirement Title", "Coverage", "Test Case", "Test Case Title", "Result", "Executed",
+	}}
+	for _, row := range m.Rows {
+		if len(row.Tests) == 0 {
+			records = append(records, []string{row.ID, row.Title, string(row.Status), "", "", "", ""})
+			continue
+		}
+		for _, test := range row.Tests {
+			// matrices decoded from JSON of older versions have no labels
+			result := test.ResultLabel
+			if result == "" {
+				result = test.Result
+			}
+			executed := ""
+			if test.Executed != nil {
+				executed = test.Executed.Format(time.RFC3339)
+			}
+			records = append(records, []string{
+				row.ID, row.Title, string(row.Status), test.ID, test.Title, result, executed,
+			})
+		}
+	}
+	return writer.WriteAll(records)
+}
+
+var coverageHTMLTemplate = template.Must(template.New("coverage").Funcs(template.FuncMap{
+	"upper": strings.ToUpper,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage Matrix</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+.passed { background: #d4edda; }
+.failed { background: #f8d7da; }
+.incomplete { background: #fff3cd; }
+.uncovered { background: #e2e3e5; }
+</style>
+</head>
+<body>
+<h1>Coverage Matrix</h1>
+<p>{{len .Rows}} requirements, {{len .Uncovered}} uncovered, {{len .Failed}} failed</p>
+{{- with .Uncovered}}
+<h2>Uncovered</h2>
+<ul>{{range .}}<li>{{.ID}} {{.Title}}</li>{{end}}</ul>
+{{- end}}
+{{- with .Failed}}
+<h2>Failed</h2>
+<ul>{{range .}}<li>{{.ID}} {{.Title}}</li>{{end}}</ul>
+{{- end}}
+<h2>Matrix</h2>
+<table>
+<tr><th>Requirement</th><th>Title</th><th>Coverage</th><th>Test Cases</th></tr>
+{{- range .Rows}}
+<tr class="{{.Status}}">
+<td>{{.ID}}</td>
+<td>{{.Title}}</td>
+<td>{{upper (printf "%s" .Status)}}</td>
+<td>{{range .Tests}}<div>{{.ID}} {{.Title}}: {{if .Result}}{{or .ResultLabel .Result}}{{else}}not executed{{end}}</div>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// HTML writes matrix as standalone HTML page
+func (m *CoverageMatrix) HTML(w io.Writer) error {
+	return coverageHTMLTemplate.Execute(w, m)
+}
@@ -0,0 +1,70 @@
+package polarion_wsdl
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoverageStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		tests []CoverageTest
+		want  CoverageStatus
+	}{
+		{"no tests", nil, CoverageUncovered},
+		{"all passed", []CoverageTest{{Result: "passed"}, {Result: "passed"}}, CoveragePassed},
+		{"failed wins", []CoverageTest{{}, {Result: "failed"}}, CoverageFailed},
+		{"not executed", []CoverageTest{{Result: "passed"}, {}}, CoverageIncomplete},
+		{"blocked", []CoverageTest{{Result: "blocked"}}, CoverageIncomplete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := coverageStatus(tt.tests); got != tt.want {
+				t.Errorf("coverageStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoverageMatrixCSV(t *testing.T) {
+	executed := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		row  CoverageRow
+		want string
+	}{
+		{
+			"uncovered",
+			CoverageRow{ID: "REQ-1", Title: "Login", Status: CoverageUncovered},
+			"REQ-1,Login,uncovered,,,,\n",
+		},
+		{
+			"labeled result",
+			CoverageRow{ID: "REQ-1", Title: "Login", Status: CoveragePassed, Tests: []CoverageTest{
+				{ID: "TC-1", Title: "Valid login", Result: "passed", ResultLabel: "Passed", Executed: &executed},
+			}},
+			"REQ-1,Login,passed,TC-1,Valid login,Passed,2024-03-01T10:00:00Z\n",
+		},
+		{
+			"result without label",
+			CoverageRow{ID: "REQ-1", Title: "Login", Status: CoverageFailed, Tests: []CoverageTest{
+				{ID: "TC-1", Title: "Valid login", Result: "failed"},
+			}},
+			"REQ-1,Login,failed,TC-1,Valid login,failed,\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			matrix := &CoverageMatrix{Rows: []CoverageRow{tt.row}}
+			if err := matrix.CSV(&b); err != nil {
+				t.Fatalf("CSV() error = %v", err)
+			}
+			_, got, _ := strings.Cut(b.String(), "\n")
+			if got != tt.want {
+				t.Errorf("CSV() row = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
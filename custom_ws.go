@@ -0,0 +1,279 @@
+package polarion_wsdl
+
+// generated tracker_ws.AnyType keeps only inner XML of custom field values,
+// but xsi:type is needed to decode them and Polarion requires it when they are set.
+// Types below mirror generated types which carry custom field values
+// and are sent with TrackerClient instead of TrackerWS.
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// CustomValue is custom field value with its XSI type, e.g. "xsd:string" or "tracker:Text"
+type CustomValue struct {
+	XSIType  string
+	InnerXML string
+}
+
+// namespaces of type prefixes used by EncodeCustomValue
+var customValueNamespaces = map[string]string{
+	"xsd":     xsdNamespace,
+	"tracker": trackerNamespace,
+}
+
+func (v CustomValue) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if v.XSIType != "" {
+		start.Attr = append(start.Attr, xml.Attr{
+			Name:  xml.Name{Space: xsiNamespace, Local: "type"},
+			Value: v.XSIType,
+		})
+		// prefix of the type has to be declared, encoder does not know it
+		if prefix, _, ok := strings.Cut(v.XSIType, ":"); ok && customValueNamespaces[prefix] != "" {
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: "xmlns:" + prefix},
+				Value: customValueNamespaces[prefix],
+			})
+		}
+	}
+
+	content := struct {
+		InnerXML string `xml:",innerxml"`
+	}{v.InnerXML}
+	return e.EncodeElement(content, start)
+}
+
+func (v *CustomValue) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		// undeclared prefix is kept as namespace by decoder
+		if attr.Name.Local == "type" && (attr.Name.Space == xsiNamespace || attr.Name.Space == "xsi") {
+			v.XSIType = attr.Value
+		}
+	}
+
+	content := struct {
+		InnerXML string `xml:",innerxml"`
+	}{}
+	if err := d.DecodeElement(&content, &start); err != nil {
+		return err
+	}
+	v.InnerXML = content.InnerXML
+	return nil
+}
+
+// Custom is tracker_ws.Custom with typed value
+type Custom struct {
+	Key   string       `xml:"key,omitempty"`
+	Value *CustomValue `xml:"value,omitempty"`
+}
+
+// ArrayOfCustom is tracker_ws.ArrayOfCustom with typed values
+type ArrayOfCustom struct {
+	Custom []*Custom `xml:"Custom,omitempty"`
+}
+
+// WorkItem is tracker_ws.WorkItem with typed custom field values,
+// custom fields of embedded generated work item are not used
+type WorkItem struct {
+	tracker_ws.WorkItem
+
+	CustomFields *ArrayOfCustom `xml:"customFields,omitempty"`
+
+	// XML names of fields present in unmarshalled work item, nil for work items built in code
+	present map[string]struct{}
+}
+
+// UnmarshalXML decodes work item and records which fields were present,
+// so fields with empty value can be told apart from fields which were not fetched
+func (wi *WorkItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	wi.present = map[string]struct{}{}
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "uri":
+			uri := tracker_ws.SubterraURI(attr.Value)
+			wi.Uri = &uri
+		case "unresolvable":
+			unresolvable, err := strconv.ParseBool(attr.Value)
+			if err != nil {
+				return fmt.Errorf("invalid unresolvable attribute '%s' of work item", attr.Value)
+			}
+			wi.Unresolvable = unresolvable
+		default:
+			continue
+		}
+		wi.present[attr.Name.Local] = struct{}{}
+	}
+
+	fields := reflect.ValueOf(&wi.WorkItem).Elem()
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			var target any
+			if index, ok := workItemFieldIndex[t.Name.Local]; ok {
+				target = fields.Field(index).Addr().Interface()
+			}
+			if t.Name.Local == "customFields" {
+				target = &wi.CustomFields
+			}
+			if target == nil {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := d.DecodeElement(target, &t); err != nil {
+				return err
+			}
+			wi.present[t.Name.Local] = struct{}{}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// reports whether work item has field with given XML name, work items built in code
+// have only fields with non-zero value
+func (wi *WorkItem) has(name string) bool {
+	if wi.present != nil {
+		_, ok := wi.present[name]
+		return ok
+	}
+	if name == "customFields" {
+		return wi.CustomFields != nil
+	}
+	index, ok := workItemFieldIndex[name]
+	return ok && !reflect.ValueOf(wi.WorkItem).Field(index).IsZero()
+}
+
+type customField struct {
+	Key           string       `xml:"key,omitempty"`
+	ParentItemURI string       `xml:"parentItemURI,omitempty"`
+	Value         *CustomValue `xml:"value,omitempty"`
+}
+
+type setCustomFieldRequest struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl setCustomField"`
+
+	CustomField *customField `xml:"customField,omitempty"`
+}
+
+type getCustomFieldResponse struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl getCustomFieldResponse"`
+
+	GetCustomFieldReturn *customField `xml:"getCustomFieldReturn,omitempty"`
+}
+
+// tracker_ws.CustomFieldType with enum ID of enum custom fields,
+// generated type drops elements of EnumCustomFieldType
+type customFieldType struct {
+	tracker_ws.CustomFieldType
+
+	EnumID string `xml:"enumId,omitempty"`
+}
+
+type getCustomFieldTypesResponse struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl getCustomFieldTypesResponse"`
+
+	GetCustomFieldTypesReturn []*customFieldType `xml:"getCustomFieldTypesReturn,omitempty"`
+}
+
+type createWorkItemRequest struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl createWorkItem"`
+
+	Content *WorkItem `xml:"content,omitempty"`
+}
+
+type updateWorkItemRequest struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl updateWorkItem"`
+
+	Content *WorkItem `xml:"content,omitempty"`
+}
+
+type getWorkItemByUriResponse struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl getWorkItemByUriResponse"`
+
+	GetWorkItemByUriReturn *WorkItem `xml:"getWorkItemByUriReturn,omitempty"`
+}
+
+type getWorkItemByUriWithFieldsResponse struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl getWorkItemByUriWithFieldsResponse"`
+
+	GetWorkItemByUriWithFieldsReturn *WorkItem `xml:"getWorkItemByUriWithFieldsReturn,omitempty"`
+}
+
+type queryWorkItemsResponse struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl queryWorkItemsResponse"`
+
+	QueryWorkItemsReturn []*WorkItem `xml:"queryWorkItemsReturn,omitempty"`
+}
+
+// gets work item with typed custom field values, all fields are fetched if fields list is empty
+func (p *Polarion) getWorkItem(ctx context.Context, uri *tracker_ws.SubterraURI, fields []string) (*WorkItem, error) {
+	if len(fields) == 0 {
+		resp := &getWorkItemByUriResponse{}
+		err := p.TrackerClient.CallContext(ctx, "''", &tracker_ws.GetWorkItemByUri{Uri: uri}, resp)
+		return resp.GetWorkItemByUriReturn, err
+	}
+
+	req := tracker_ws.GetWorkItemByUriWithFields{
+		Uri:  uri,
+		Keys: fields,
+	}
+	resp := &getWorkItemByUriWithFieldsResponse{}
+	err := p.TrackerClient.CallContext(ctx, "''", &req, resp)
+	return resp.GetWorkItemByUriWithFieldsReturn, err
+}
+
+// queries work items with typed custom field values
+func (p *Polarion) queryWorkItems(ctx context.Context, query, sortField string, fields []string) ([]*WorkItem, error) {
+	req := tracker_ws.QueryWorkItems{
+		Query:  query,
+		Sort:   sortField,
+		Fields: fields,
+	}
+	resp := &queryWorkItemsResponse{}
+	err := p.TrackerClient.CallContext(ctx, "''", &req, resp)
+	return resp.QueryWorkItemsReturn, err
+}
+
+// custom field types of work item by custom field key
+func (p *Polarion) customFieldTypes(ctx context.Context, uri *tracker_ws.SubterraURI) (map[string]*customFieldType, error) {
+	resp := &getCustomFieldTypesResponse{}
+	err := p.TrackerClient.CallContext(ctx, "''", &tracker_ws.GetCustomFieldTypes{WorkitemURI: uri}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom field types of work item %s: %v", *uri, err)
+	}
+
+	types := map[string]*customFieldType{}
+	for _, fieldType := range resp.GetCustomFieldTypesReturn {
+		if fieldType != nil && fieldType.Id != nil {
+			types[*fieldType.Id] = fieldType
+		}
+	}
+	return types, nil
+}
+
+func (p *Polarion) setCustomField(ctx context.Context, uri *tracker_ws.SubterraURI, key string, value *CustomValue) error {
+	req := setCustomFieldRequest{
+		CustomField: &customField{
+			Key:           key,
+			ParentItemURI: string(*uri),
+			Value:         value,
+		},
+	}
+	return p.TrackerClient.CallContext(ctx, "''", &req, &tracker_ws.SetCustomFieldResponse{})
+}
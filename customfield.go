@@ -0,0 +1,332 @@
+package polarion_wsdl
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+	"github.com/hooklift/gowsdl/soap"
+)
+
+const (
+	xsdNamespace     = "http://www.w3.org/2001/XMLSchema"
+	trackerNamespace = "http://ws.polarion.com/TrackerWebService-types"
+)
+
+// EnumID is value of single enum custom field,
+// separate type is needed to encode it differently from plain string
+type EnumID string
+
+// Date is time.Time encoded as value of date-only custom field (time part is ignored),
+// dates are decoded as time.Time at midnight of local time
+type Date struct {
+	time.Time
+}
+
+// DecodeCustomValue converts custom field value to Go value based on its XSI type:
+//
+//	xsd:string                   string
+//	xsd:int, long, short         int
+//	xsd:float, double, decimal   float64
+//	xsd:boolean                  bool
+//	xsd:date, xsd:dateTime       time.Time
+//	Duration                     tracker_ws.Duration
+//	Currency                     tracker_ws.Currency
+//	Text                         *model.Text
+//	EnumOptionId                 EnumID
+//	ArrayOfEnumOptionId          []string
+//	Table                        *model.Table
+//
+// nil is returned for empty value.
+func DecodeCustomValue(value *CustomValue) (any, error) {
+	if value == nil || (value.XSIType == "" && value.InnerXML == "") {
+		return nil, nil
+	}
+
+	xsiType := localName(value.XSIType)
+	switch xsiType {
+	case "", "string", "anyURI":
+		var s string
+		err := unmarshalInner(value.InnerXML, &s)
+		return s, err
+	case "int", "long", "short", "integer":
+		s, err := innerText(value.InnerXML)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.Atoi(s)
+	case "float", "double", "decimal":
+		s, err := innerText(value.InnerXML)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(s, 64)
+	case "boolean":
+		s, err := innerText(value.InnerXML)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseBool(s)
+	case "date":
+		var d soap.XSDDate
+		if err := unmarshalInner(value.InnerXML, &d); err != nil {
+			return nil, err
+		}
+		return model.FromXSDDate(d), nil
+	case "dateTime":
+		var dt soap.XSDDateTime
+		if err := unmarshalInner(value.InnerXML, &dt); err != nil {
+			return nil, err
+		}
+		return model.FromXSDDateTime(dt), nil
+	case "Duration":
+		s, err := innerText(value.InnerXML)
+		return tracker_ws.Duration(s), err
+	case "Currency":
+		s, err := innerText(value.InnerXML)
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return tracker_ws.Currency(f), err
+	case "Text":
+		var t tracker_ws.Text
+		err := unmarshalInner(value.InnerXML, &t)
+		return model.TextFromWS(&t), err
+	case "EnumOptionId":
+		var opt tracker_ws.EnumOptionId
+		err := unmarshalInner(value.InnerXML, &opt)
+		return EnumID(model.EnumID(&opt)), err
+	case "ArrayOfEnumOptionId":
+		var opts tracker_ws.ArrayOfEnumOptionId
+		if err := unmarshalInner(value.InnerXML, &opts); err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(opts.EnumOptionId))
+		for _, opt := range opts.EnumOptionId {
+			ids = append(ids, model.EnumID(opt))
+		}
+		return ids, nil
+	case "Table":
+		var t tracker_ws.Table
+		err := unmarshalInner(value.InnerXML, &t)
+		return model.TableFromWS(&t), err
+	}
+
+	return nil, fmt.Errorf("unsupported custom field value type '%s'", value.XSIType)
+}
+
+// EncodeCustomValue converts Go value to custom field value,
+// supported types are the ones returned by DecodeCustomValue and Duration,
+// CustomValue is used as is. time.Time is encoded as xsd:dateTime,
+// Date has to be used for date-only fields.
+func EncodeCustomValue(value any) (*CustomValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case *CustomValue:
+		return v, nil
+	case CustomValue:
+		return &v, nil
+	case string:
+		return xsdValue("string", v), nil
+	case int:
+		return xsdValue("int", strconv.Itoa(v)), nil
+	case int32:
+		return xsdValue("int", strconv.FormatInt(int64(v), 10)), nil
+	case int64:
+		return xsdValue("long", strconv.FormatInt(v, 10)), nil
+	case float32:
+		return xsdValue("float", strconv.FormatFloat(float64(v), 'f', -1, 32)), nil
+	case float64:
+		return xsdValue("double", strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		return xsdValue("boolean", strconv.FormatBool(v)), nil
+	case time.Time:
+		return xsdValue("dateTime", v.Format(time.RFC3339)), nil
+	case Date:
+		return xsdValue("date", v.Format(time.DateOnly)), nil
+	case tracker_ws.Duration:
+		return trackerValue("Duration", escapeText(string(v))), nil
+	case Duration:
+		return trackerValue("Duration", escapeText(v.String())), nil
+	case *Duration:
+		return trackerValue("Duration", escapeText(v.String())), nil
+	case tracker_ws.Currency:
+		return trackerValue("Currency", strconv.FormatFloat(float64(v), 'f', -1, 64)), nil
+	case *model.Text:
+		return marshalTrackerValue("Text", v.ToWS())
+	case model.Text:
+		return marshalTrackerValue("Text", v.ToWS())
+	case EnumID:
+		return marshalTrackerValue("EnumOptionId", model.NewEnumID(string(v)))
+	case []string:
+		opts := tracker_ws.ArrayOfEnumOptionId{}
+		for _, id := range v {
+			opts.EnumOptionId = append(opts.EnumOptionId, model.NewEnumID(id))
+		}
+		return marshalTrackerValue("ArrayOfEnumOptionId", &opts)
+	case []EnumID:
+		ids := make([]string, 0, len(v))
+		for _, id := range v {
+			ids = append(ids, string(id))
+		}
+		return EncodeCustomValue(ids)
+	case *model.Table:
+		return marshalTrackerValue("Table", v.ToWS())
+	}
+
+	return nil, fmt.Errorf("unsupported custom field value type %T", value)
+}
+
+// DecodeCustomFields decodes all custom fields of work item by key
+func DecodeCustomFields(fields *ArrayOfCustom) (map[string]any, error) {
+	values := map[string]any{}
+	if fields == nil {
+		return values, nil
+	}
+
+	for _, field := range fields.Custom {
+		if field == nil {
+			continue
+		}
+		value, err := DecodeCustomValue(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode custom field '%s': %v", field.Key, err)
+		}
+		values[field.Key] = value
+	}
+
+	return values, nil
+}
+
+// value converted to Go type which EncodeCustomValue encodes as given XSI type,
+// so decoded values can be written back: time.Time of date fields becomes Date
+// and strings of enum fields become EnumID
+func customValueAs(value any, xsiType string) any {
+	switch v := value.(type) {
+	case time.Time:
+		if localName(xsiType) == "date" {
+			return Date{v}
+		}
+	case string:
+		if localName(xsiType) == "EnumOptionId" {
+			return EnumID(v)
+		}
+	}
+	return value
+}
+
+// raw value of custom field with given key, reports whether the field is present
+func customValue(fields *ArrayOfCustom, key string) (*CustomValue, bool) {
+	if fields == nil {
+		return nil, false
+	}
+	for _, field := range fields.Custom {
+		if field != nil && field.Key == key {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+// GetCustomFieldValue returns decoded value of work item custom field
+func (p *Polarion) GetCustomFieldValue(ctx context.Context, wiURI *tracker_ws.SubterraURI, key string) (any, error) {
+	req := tracker_ws.GetCustomField{
+		WorkitemURI: wiURI,
+		Key:         key,
+	}
+	resp := &getCustomFieldResponse{}
+	if err := p.TrackerClient.CallContext(ctx, "''", &req, resp); err != nil {
+		return nil, fmt.Errorf("failed to get WorkItem CustomField with key '%s': %v", key, err)
+	}
+	field := resp.GetCustomFieldReturn
+	if field == nil {
+		return nil, nil
+	}
+
+	value, err := DecodeCustomValue(field.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode WorkItem CustomField with key '%s': %v", key, err)
+	}
+	return value, nil
+}
+
+// SetCustomField sets work item custom field to encoded Go value, nil clears the field
+func (p *Polarion) SetCustomField(ctx context.Context, wiURI *tracker_ws.SubterraURI, key string, value any) error {
+	if wiURI == nil {
+		return fmt.Errorf("work item URI is required to set CustomField with key '%s'", key)
+	}
+
+	encoded, err := EncodeCustomValue(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode WorkItem CustomField with key '%s': %v", key, err)
+	}
+
+	if err := p.setCustomField(ctx, wiURI, key, encoded); err != nil {
+		return fmt.Errorf("failed to set WorkItem CustomField with key '%s': %v", key, err)
+	}
+	return nil
+}
+
+// "ns:Text" -> "Text"
+func localName(qname string) string {
+	if i := strings.LastIndex(qname, ":"); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+func unmarshalInner(inner string, v any) error {
+	return xml.Unmarshal([]byte("<value>"+inner+"</value>"), v)
+}
+
+func innerText(inner string) (string, error) {
+	var s string
+	err := unmarshalInner(inner, &s)
+	return strings.TrimSpace(s), err
+}
+
+func escapeText(s string) string {
+	var b bytes.Buffer
+	// writes to bytes.Buffer do not fail
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func xsdValue(xsdType, text string) *CustomValue {
+	return &CustomValue{
+		XSIType:  "xsd:" + xsdType,
+		InnerXML: escapeText(text),
+	}
+}
+
+func trackerValue(trackerType, innerXML string) *CustomValue {
+	return &CustomValue{
+		XSIType:  "tracker:" + trackerType,
+		InnerXML: innerXML,
+	}
+}
+
+// marshals v and uses its content (without root element) as value
+func marshalTrackerValue(trackerType string, v any) (*CustomValue, error) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	start := bytes.IndexByte(b, '>')
+	end := bytes.LastIndex(b, []byte("</"))
+	if start < 0 || end < start {
+		// self-closing or empty element has no content
+		return trackerValue(trackerType, ""), nil
+	}
+
+	return trackerValue(trackerType, string(b[start+1:end])), nil
+}
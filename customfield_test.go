@@ -0,0 +1,293 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func TestCustomValueRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		xsiType string
+		want    any
+	}{
+		{"string", "a < b", "xsd:string", "a < b"},
+		{"int", 42, "xsd:int", 42},
+		{"int64", int64(42), "xsd:long", 42},
+		{"float", 1.5, "xsd:double", 1.5},
+		{"bool", true, "xsd:boolean", true},
+		{
+			"date time", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), "xsd:dateTime",
+			time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			// dates have no time zone and are decoded in local time
+			"date", Date{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, "xsd:date",
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local),
+		},
+		{"generated duration", tracker_ws.Duration("1d 2h"), "tracker:Duration", tracker_ws.Duration("1d 2h")},
+		{"duration", Duration{Days: 1, Minutes: 90}, "tracker:Duration", tracker_ws.Duration("1d 1h 30m")},
+		{"currency", tracker_ws.Currency(9.99), "tracker:Currency", tracker_ws.Currency(9.99)},
+		{"enum", EnumID("high"), "tracker:EnumOptionId", EnumID("high")},
+		{"multi enum", []string{"a", "b"}, "tracker:ArrayOfEnumOptionId", []string{"a", "b"}},
+		{"multi enum IDs", []EnumID{"a", "b"}, "tracker:ArrayOfEnumOptionId", []string{"a", "b"}},
+		{"text", model.NewPlainText("hello"), "tracker:Text", model.NewPlainText("hello")},
+		{"nil", nil, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := EncodeCustomValue(tt.value)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if tt.value == nil {
+				if encoded != nil {
+					t.Fatalf("nil encoded as %+v", encoded)
+				}
+				return
+			}
+			if encoded.XSIType != tt.xsiType {
+				t.Errorf("XSI type = %q, want %q", encoded.XSIType, tt.xsiType)
+			}
+
+			// value goes through XML as it would be sent to and received from Polarion
+			b, err := xml.Marshal(&Custom{Key: "k", Value: encoded})
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var field Custom
+			if err := xml.Unmarshal(b, &field); err != nil {
+				t.Fatalf("unmarshal %s: %v", b, err)
+			}
+
+			decoded, err := DecodeCustomValue(field.Value)
+			if err != nil {
+				t.Fatalf("decode %s: %v", b, err)
+			}
+			if !reflect.DeepEqual(decoded, tt.want) {
+				t.Errorf("decoded %#v, want %#v", decoded, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCustomValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		want    any
+		wantErr bool
+	}{
+		{
+			"server string",
+			`<value xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:ns1="http://www.w3.org/2001/XMLSchema" xsi:type="ns1:string">abc</value>`,
+			"abc", false,
+		},
+		{
+			"server date",
+			`<value xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="xsd:date">2024-03-01</value>`,
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), false,
+		},
+		{
+			"undeclared xsi prefix",
+			`<value xsi:type="xsd:int"> 7 </value>`,
+			7, false,
+		},
+		{
+			"enum",
+			`<value xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="ns2:EnumOptionId"><id>must</id></value>`,
+			EnumID("must"), false,
+		},
+		{
+			"untyped is string",
+			`<value>plain</value>`,
+			"plain", false,
+		},
+		{
+			"empty",
+			`<value/>`,
+			nil, false,
+		},
+		{
+			"unsupported",
+			`<value xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="tracker:Unknown">x</value>`,
+			nil, true,
+		},
+		{
+			"invalid number",
+			`<value xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="xsd:int">x</value>`,
+			nil, true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value CustomValue
+			if err := xml.Unmarshal([]byte(tt.xml), &value); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			got, err := DecodeCustomValue(&value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomValueMarshalXML(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []string
+	}{
+		{"xsd type", "x", []string{`:type="xsd:string"`, `xmlns:xsd="http://www.w3.org/2001/XMLSchema"`, ">x</value>"}},
+		{"tracker type", EnumID("a"), []string{`:type="tracker:EnumOptionId"`, `xmlns:tracker="` + trackerNamespace + `"`, "<id>a</id>"}},
+		{"escaped", "<b>", []string{"&lt;b&gt;"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := EncodeCustomValue(tt.value)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			b, err := xml.Marshal(struct {
+				XMLName xml.Name     `xml:"field"`
+				Value   *CustomValue `xml:"value"`
+			}{Value: encoded})
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("%s does not contain %s", b, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeCustomValueUnsupported(t *testing.T) {
+	if _, err := EncodeCustomValue(struct{}{}); err == nil {
+		t.Fatal("expected error for unsupported type")
+	}
+}
+
+func TestDecodeCustomFields(t *testing.T) {
+	fields := &ArrayOfCustom{Custom: []*Custom{
+		{Key: "a", Value: &CustomValue{XSIType: "xsd:string", InnerXML: "x"}},
+		nil,
+		{Key: "b"},
+	}}
+
+	values, err := DecodeCustomFields(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"a": "x", "b": nil}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("decoded %v, want %v", values, want)
+	}
+
+	fields.Custom = append(fields.Custom, &Custom{Key: "c", Value: &CustomValue{XSIType: "xsd:int", InnerXML: "x"}})
+	if _, err := DecodeCustomFields(fields); err == nil || !strings.Contains(err.Error(), "'c'") {
+		t.Errorf("error = %v, want error for field 'c'", err)
+	}
+}
+
+func TestSetCustomFieldNilURI(t *testing.T) {
+	p := &Polarion{}
+	if err := p.SetCustomField(context.Background(), nil, "asil", "A"); err == nil {
+		t.Fatal("expected error for nil work item URI")
+	}
+}
+
+func TestWorkItemCustomFieldsXML(t *testing.T) {
+	response := `<queryWorkItemsResponse xmlns="http://ws.polarion.com/TrackerWebService-impl">
+		<queryWorkItemsReturn uri="subterra:data-service:objects:/default/demo${WorkItem}DEMO-1">
+			<id xmlns="">DEMO-1</id>
+			<customFields xmlns="">
+				<Custom>
+					<key>due</key>
+					<value xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:ns1="http://www.w3.org/2001/XMLSchema" xsi:type="ns1:date">2024-03-01</value>
+				</Custom>
+			</customFields>
+		</queryWorkItemsReturn>
+	</queryWorkItemsResponse>`
+
+	var resp queryWorkItemsResponse
+	if err := xml.Unmarshal([]byte(response), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.QueryWorkItemsReturn) != 1 {
+		t.Fatalf("got %d work items", len(resp.QueryWorkItemsReturn))
+	}
+	wi := resp.QueryWorkItemsReturn[0]
+	if wi.Id != "DEMO-1" || model.URI(wi.Uri) == "" {
+		t.Errorf("generated fields not decoded: %+v", wi.WorkItem)
+	}
+
+	// work item survives marshalling, as tracked work item snapshot does
+	b, err := xml.Marshal(wi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := &WorkItem{}
+	if err := xml.Unmarshal(b, restored); err != nil {
+		t.Fatal(err)
+	}
+	values, err := DecodeCustomFields(restored.CustomFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if due, ok := values["due"].(time.Time); !ok || due.Format(time.DateOnly) != "2024-03-01" {
+		t.Errorf("due = %#v, want date 2024-03-01", values["due"])
+	}
+}
+
+func TestCustomFieldTypesXML(t *testing.T) {
+	response := `<getCustomFieldTypesResponse xmlns="http://ws.polarion.com/TrackerWebService-impl">
+		<getCustomFieldTypesReturn xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="ns1:EnumCustomFieldType">
+			<id xmlns="">asil</id>
+			<multi xmlns="">false</multi>
+			<enumId xmlns="">asil</enumId>
+		</getCustomFieldTypesReturn>
+		<getCustomFieldTypesReturn>
+			<id xmlns="">note</id>
+		</getCustomFieldTypesReturn>
+	</getCustomFieldTypesResponse>`
+
+	var resp getCustomFieldTypesResponse
+	if err := xml.Unmarshal([]byte(response), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		enumID string
+	}{
+		{"asil", "asil"},
+		{"note", ""},
+	}
+	if len(resp.GetCustomFieldTypesReturn) != len(tests) {
+		t.Fatalf("got %d custom field types", len(resp.GetCustomFieldTypesReturn))
+	}
+	for i, tt := range tests {
+		fieldType := resp.GetCustomFieldTypesReturn[i]
+		if fieldType.Id == nil || *fieldType.Id != tt.key || fieldType.EnumID != tt.enumID {
+			t.Errorf("custom field type %d = %+v, want key %s enum %q", i, fieldType, tt.key, tt.enumID)
+		}
+	}
+}
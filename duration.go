@@ -0,0 +1,261 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// Duration is Polarion work duration such as "1d 4h 30m" or "1/2d".
+// Days are kept separately from hours and minutes
+// because length of the day is configured on server (see DayLength).
+type Duration struct {
+	Days    float64
+	Minutes float64
+}
+
+// ParseDuration parses Polarion duration syntax: space separated numbers with
+// "d", "h" or "m" units, numbers may be decimal ("1.5h") or fractions ("1/2d").
+// Leading "-" negates whole duration ("-1d 2h"), "-" of other parts subtracts them ("1d -2h").
+// Empty string is zero duration.
+func ParseDuration(s string) (Duration, error) {
+	var d Duration
+	negative := false
+	for i, part := range strings.Fields(strings.ToLower(s)) {
+		sign := 1.0
+		if after, ok := strings.CutPrefix(part, "-"); ok {
+			part = after
+			if i == 0 {
+				negative = true
+			} else {
+				sign = -1
+			}
+		}
+		if len(part) < 2 {
+			return Duration{}, fmt.Errorf("invalid duration '%s': missing unit in '%s'", s, part)
+		}
+
+		value, err := parseDurationNumber(part[:len(part)-1])
+		if err != nil {
+			return Duration{}, fmt.Errorf("invalid duration '%s': %v", s, err)
+		}
+		value *= sign
+
+		switch part[len(part)-1] {
+		case 'd':
+			d.Days += value
+		case 'h':
+			d.Minutes += value * 60
+		case 'm':
+			d.Minutes += value
+		default:
+			return Duration{}, fmt.Errorf("invalid duration '%s': unknown unit in '%s'", s, part)
+		}
+	}
+
+	if negative {
+		return Duration{}.Sub(d), nil
+	}
+	return d, nil
+}
+
+// "1.5", "1/2"
+func parseDurationNumber(s string) (float64, error) {
+	numerator, denominator, isFraction := strings.Cut(s, "/")
+	value, err := strconv.ParseFloat(numerator, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid number '%s'", s)
+	}
+	if !isFraction {
+		return value, nil
+	}
+
+	divisor, err := strconv.ParseFloat(denominator, 64)
+	if err != nil || divisor <= 0 {
+		return 0, fmt.Errorf("invalid fraction '%s'", s)
+	}
+	return value / divisor, nil
+}
+
+// DurationFromWS parses generated duration, nil is zero duration
+func DurationFromWS(d *tracker_ws.Duration) (Duration, error) {
+	if d == nil {
+		return Duration{}, nil
+	}
+	return ParseDuration(string(*d))
+}
+
+// DurationOf converts time.Duration to Duration carrying full days of given length
+func DurationOf(d, dayLength time.Duration) Duration {
+	return Duration{Minutes: d.Minutes()}.Normalize(dayLength)
+}
+
+// String formats duration in Polarion syntax, e.g. "1d 4h 30m".
+// Negative duration has single sign prefix ("-1d 2h"), when days and minutes
+// have different signs the negative part is subtracted ("1d -2h").
+func (d Duration) String() string {
+	// rounded before splitting into units, so 59.999m is "1h" and not "60m"
+	days, minutes := roundDurationNumber(d.Days), roundDurationNumber(d.Minutes)
+	positive := Duration{Days: max(days, 0), Minutes: max(minutes, 0)}
+	negative := Duration{Days: max(-days, 0), Minutes: max(-minutes, 0)}
+
+	switch {
+	case positive.IsZero() && negative.IsZero():
+		return "0h"
+	case positive.IsZero():
+		return "-" + strings.Join(negative.parts(), " ")
+	}
+
+	parts := positive.parts()
+	for _, part := range negative.parts() {
+		parts = append(parts, "-"+part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// units of non-negative duration
+func (d Duration) parts() []string {
+	var parts []string
+	if d.Days != 0 {
+		parts = append(parts, formatDurationNumber(d.Days)+"d")
+	}
+
+	hours := math.Floor(d.Minutes / 60)
+	if hours != 0 {
+		parts = append(parts, formatDurationNumber(hours)+"h")
+	}
+	if minutes := roundDurationNumber(d.Minutes - hours*60); minutes != 0 {
+		parts = append(parts, formatDurationNumber(minutes)+"m")
+	}
+	return parts
+}
+
+func roundDurationNumber(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+func formatDurationNumber(f float64) string {
+	return strconv.FormatFloat(roundDurationNumber(f), 'f', -1, 64)
+}
+
+// ToWS converts to generated duration, nil for zero duration
+func (d Duration) ToWS() *tracker_ws.Duration {
+	if d.IsZero() {
+		return nil
+	}
+	duration := tracker_ws.Duration(d.String())
+	return &duration
+}
+
+func (d Duration) IsZero() bool {
+	return d.Days == 0 && d.Minutes == 0
+}
+
+func (d Duration) Add(other Duration) Duration {
+	return Duration{
+		Days:    d.Days + other.Days,
+		Minutes: d.Minutes + other.Minutes,
+	}
+}
+
+func (d Duration) Sub(other Duration) Duration {
+	return Duration{
+		Days:    d.Days - other.Days,
+		Minutes: d.Minutes - other.Minutes,
+	}
+}
+
+// Hours returns total number of hours for given day length
+func (d Duration) Hours(dayLength time.Duration) float64 {
+	return d.Days*dayLength.Hours() + d.Minutes/60
+}
+
+// ToDuration converts to time.Duration using given day length
+func (d Duration) ToDuration(dayLength time.Duration) time.Duration {
+	return time.Duration(d.Days*float64(dayLength)) + time.Duration(d.Minutes*float64(time.Minute))
+}
+
+// Normalize moves full days from minutes to days, e.g. "10h" becomes "1d 2h" for 8 hour days,
+// days and minutes of normalized duration have the same sign
+func (d Duration) Normalize(dayLength time.Duration) Duration {
+	dayMinutes := dayLength.Minutes()
+	if dayMinutes <= 0 {
+		return d
+	}
+
+	total := d.Days*dayMinutes + d.Minutes
+	days := math.Trunc(total / dayMinutes)
+	return Duration{Days: days, Minutes: total - days*dayMinutes}
+}
+
+// SumDurations adds up durations, e.g. time spent of several work records
+func SumDurations(durations ...Duration) Duration {
+	var sum Duration
+	for _, d := range durations {
+		sum = sum.Add(d)
+	}
+	return sum
+}
+
+// server day length, loaded once
+type dayLengthCache struct {
+	mu    sync.Mutex
+	value time.Duration
+}
+
+// DayLength returns length of one working day configured in Polarion, value is cached
+func (p *Polarion) DayLength(ctx context.Context) (time.Duration, error) {
+	p.dayLength.mu.Lock()
+	defer p.dayLength.mu.Unlock()
+
+	if p.dayLength.value > 0 {
+		return p.dayLength.value, nil
+	}
+
+	resp, err := p.TrackerWS.GetOneDayLengthContext(ctx, &tracker_ws.GetOneDayLength{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get one day length: %v", err)
+	}
+
+	// day length is returned in milliseconds, e.g. 28800000 for 8 hours,
+	// unexpected values fall back to hours of "1d" duration
+	dayLength := time.Duration(resp.GetOneDayLengthReturn) * time.Millisecond
+	if dayLength <= 0 || dayLength > 24*time.Hour {
+		hours, err := p.DurationHours(ctx, "1d")
+		if err != nil {
+			return 0, err
+		}
+		dayLength = time.Duration(hours * float64(time.Hour))
+	}
+
+	p.dayLength.value = dayLength
+	return dayLength, nil
+}
+
+// DurationHours returns number of hours in duration as calculated by Polarion
+func (p *Polarion) DurationHours(ctx context.Context, duration string) (float64, error) {
+	req := tracker_ws.GetDurationHours{
+		Duration: duration,
+	}
+
+	resp, err := p.TrackerWS.GetDurationHoursContext(ctx, &req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get duration '%s' hours: %v", duration, err)
+	}
+	return float64(resp.GetDurationHoursReturn), nil
+}
+
+// ToDuration converts Polarion duration to time.Duration using server day length
+func (p *Polarion) ToDuration(ctx context.Context, d Duration) (time.Duration, error) {
+	dayLength, err := p.DayLength(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return d.ToDuration(dayLength), nil
+}
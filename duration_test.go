@@ -0,0 +1,129 @@
+package polarion_wsdl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Duration
+		wantErr bool
+	}{
+		{"", Duration{}, false},
+		{"1d 4h 30m", Duration{Days: 1, Minutes: 270}, false},
+		{"1.5h", Duration{Minutes: 90}, false},
+		{"1/2d", Duration{Days: 0.5}, false},
+		{" 2H  15M ", Duration{Minutes: 135}, false},
+		{"-1d 2h", Duration{Days: -1, Minutes: -120}, false},
+		{"1d -2h", Duration{Days: 1, Minutes: -120}, false},
+		{"-30m", Duration{Minutes: -30}, false},
+		{"1", Duration{}, true},
+		{"1w", Duration{}, true},
+		{"xh", Duration{}, true},
+		{"1/0d", Duration{}, true},
+		{"--1h", Duration{}, true},
+		{"-", Duration{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Duration
+		want string
+	}{
+		{"zero", Duration{}, "0h"},
+		{"all units", Duration{Days: 1, Minutes: 270}, "1d 4h 30m"},
+		{"fraction of day", Duration{Days: 0.5}, "0.5d"},
+		{"minutes only", Duration{Minutes: 45}, "45m"},
+		{"rounded to hour", Duration{Minutes: 59.999}, "1h"},
+		{"rounded minutes", Duration{Minutes: 90.004}, "1h 30m"},
+		{"below rounding", Duration{Minutes: 0.001}, "0h"},
+		{"negative", Duration{Days: -1, Minutes: -150}, "-1d 2h 30m"},
+		{"negative minutes", Duration{Minutes: -30}, "-30m"},
+		{"negative minutes of positive days", Duration{Days: 1, Minutes: -90}, "1d -1h -30m"},
+		{"negative days of positive minutes", Duration{Days: -1, Minutes: 120}, "2h -1d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in.String()
+			if got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+
+			// formatted duration is parsed back to the same value
+			parsed, err := ParseDuration(got)
+			if err != nil {
+				t.Fatalf("ParseDuration(%q): %v", got, err)
+			}
+			if parsed.String() != got {
+				t.Errorf("ParseDuration(%q) formats as %q", got, parsed.String())
+			}
+		})
+	}
+}
+
+func TestDurationArithmetic(t *testing.T) {
+	day := 8 * time.Hour
+
+	tests := []struct {
+		name      string
+		got       Duration
+		want      Duration
+		wantHours float64
+	}{
+		{"add", Duration{Days: 1, Minutes: 30}.Add(Duration{Minutes: 45}), Duration{Days: 1, Minutes: 75}, 9.25},
+		{"sub", Duration{Days: 1}.Sub(Duration{Minutes: 120}), Duration{Days: 1, Minutes: -120}, 6},
+		{"sum", SumDurations(Duration{Minutes: 30}, Duration{Days: 0.5}, Duration{Minutes: 90}), Duration{Days: 0.5, Minutes: 120}, 6},
+		{"sum of none", SumDurations(), Duration{}, 0},
+		{"normalize", Duration{Minutes: 600}.Normalize(day), Duration{Days: 1, Minutes: 120}, 10},
+		{"normalize negative", Duration{Minutes: -600}.Normalize(day), Duration{Days: -1, Minutes: -120}, -10},
+		{"normalize mixed signs", Duration{Days: 1, Minutes: -120}.Normalize(day), Duration{Minutes: 360}, 6},
+		{"normalize without day length", Duration{Minutes: 600}.Normalize(0), Duration{Minutes: 600}, 10},
+		{"of time duration", DurationOf(17*time.Hour+30*time.Minute, day), Duration{Days: 2, Minutes: 90}, 17.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+			if hours := tt.got.Hours(day); hours != tt.wantHours {
+				t.Errorf("Hours() = %v, want %v", hours, tt.wantHours)
+			}
+			if d := tt.got.ToDuration(day); d != time.Duration(tt.wantHours*float64(time.Hour)) {
+				t.Errorf("ToDuration() = %v, want %vh", d, tt.wantHours)
+			}
+		})
+	}
+}
+
+func TestDurationToWS(t *testing.T) {
+	if (Duration{}).ToWS() != nil {
+		t.Error("zero duration converted to non-nil value")
+	}
+
+	ws := Duration{Days: -1}.ToWS()
+	if ws == nil || *ws != "-1d" {
+		t.Fatalf("ToWS() = %v, want -1d", ws)
+	}
+	d, err := DurationFromWS(ws)
+	if err != nil || d != (Duration{Days: -1}) {
+		t.Errorf("DurationFromWS(%q) = %+v, %v", *ws, d, err)
+	}
+}
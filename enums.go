@@ -0,0 +1,312 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// how long loaded enum options are used when TTL is not set with SetEnumCacheTTL
+const defaultEnumTTL = 10 * time.Minute
+
+// enums of work item fields loaded by WarmEnumCache
+var standardEnumIDs = []string{"type", "status", "resolution", "severity", "priority"}
+
+// EnumValue is enum option with its properties unwrapped
+type EnumValue struct {
+	EnumID   string
+	ID       string
+	Name     string
+	Sequence int32
+	Default  bool
+	Hidden   bool
+	Phantom  bool
+
+	// common properties, all of them are in Properties
+	Color       string
+	IconURL     string
+	Description string
+	Properties  map[string]string
+
+	// numeric value of priority options (PriorityOpt float), 0 for other enums
+	Priority float64
+}
+
+// tracker_ws.EnumOption with float value of priority options,
+// generated type drops elements of PriorityOpt
+type enumOption struct {
+	tracker_ws.EnumOption
+
+	Float *float64 `xml:"float,omitempty"`
+}
+
+// response of enum option operations, options are in "<operation>Return" elements
+type enumOptionsResponse struct {
+	Options []*enumOption `xml:",any"`
+}
+
+func enumValueFromWS(opt *enumOption, enumID string) EnumValue {
+	value := EnumValue{
+		EnumID:     enumID,
+		Sequence:   opt.SequenceNumber,
+		Default:    opt.Default_,
+		Hidden:     opt.Hidden,
+		Phantom:    opt.Phantom,
+		Properties: map[string]string{},
+	}
+	if opt.EnumId != nil && *opt.EnumId != "" {
+		value.EnumID = *opt.EnumId
+	}
+	if opt.Id != nil {
+		value.ID = *opt.Id
+	}
+	if opt.Name != nil {
+		value.Name = *opt.Name
+	}
+	if opt.Properties != nil {
+		for _, property := range opt.Properties.Property {
+			if property != nil && property.Value != nil {
+				value.Properties[property.Key] = *property.Value
+			}
+		}
+	}
+	value.Color = value.Properties["color"]
+	value.IconURL = value.Properties["iconURL"]
+	value.Description = value.Properties["description"]
+
+	// priority options are identified by their float value, which is also their ID
+	if enumID == "priority" {
+		if opt.Float != nil {
+			value.Priority = *opt.Float
+		} else {
+			value.Priority, _ = strconv.ParseFloat(value.ID, 64)
+		}
+	}
+	return value
+}
+
+// Label returns name of option or its ID if it has no name
+func (v EnumValue) Label() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.ID
+}
+
+// options of enum (or enum of field when byKey) in project,
+// type specific options are cached separately
+type enumKey struct {
+	projectID string
+	typeID    string
+	enumID    string
+	byKey     bool
+}
+
+type enumEntry struct {
+	values []EnumValue
+	loaded time.Time
+}
+
+type enumCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[enumKey]enumEntry
+}
+
+func (c *enumCache) get(key enumKey) ([]EnumValue, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.ttl
+	if ttl == 0 {
+		ttl = defaultEnumTTL
+	}
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.loaded) > ttl {
+		return nil, false
+	}
+	return entry.values, true
+}
+
+func (c *enumCache) set(key enumKey, values []EnumValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[enumKey]enumEntry{}
+	}
+	c.entries[key] = enumEntry{values: values, loaded: time.Now()}
+}
+
+// SetEnumCacheTTL sets how long enum options are cached, negative TTL disables caching
+func (p *Polarion) SetEnumCacheTTL(ttl time.Duration) {
+	p.enums.mu.Lock()
+	defer p.enums.mu.Unlock()
+	p.enums.ttl = ttl
+}
+
+// InvalidateEnumCache drops all cached enum options
+func (p *Polarion) InvalidateEnumCache() {
+	p.enums.mu.Lock()
+	defer p.enums.mu.Unlock()
+	p.enums.entries = nil
+}
+
+// EnumOptions returns options of enum (e.g. "status") in project.
+// With type ID options specific to that work item type are returned if there are any.
+func (p *Polarion) EnumOptions(ctx context.Context, projectID, typeID, enumID string) ([]EnumValue, error) {
+	return p.cachedEnumOptions(ctx, enumKey{projectID: projectID, typeID: typeID, enumID: enumID})
+}
+
+// error if option is not defined in enum of project, what names the option in error
+func (p *Polarion) checkEnumOption(ctx context.Context, projectID, enumID, what, id string) error {
+	options, err := p.EnumOptions(ctx, projectID, "", enumID)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(options))
+	for _, option := range options {
+		ids = append(ids, option.ID)
+	}
+	return checkProjectID(what, id, projectID, ids)
+}
+
+// FieldEnumOptions returns options of enum used by field (custom field key or "status", ...)
+func (p *Polarion) FieldEnumOptions(ctx context.Context, projectID, typeID, key string) ([]EnumValue, error) {
+	return p.cachedEnumOptions(ctx, enumKey{projectID: projectID, typeID: typeID, enumID: key, byKey: true})
+}
+
+// EnumOption returns option of enum with given ID, nil if there is no such option
+func (p *Polarion) EnumOption(ctx context.Context, projectID, typeID, enumID, optionID string) (*EnumValue, error) {
+	values, err := p.EnumOptions(ctx, projectID, typeID, enumID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range values {
+		if values[i].ID == optionID {
+			return &values[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// WarmEnumCache loads enums in advance, standard work item enums are loaded if none is given.
+// Type specific options are loaded for all types of project.
+func (p *Polarion) WarmEnumCache(ctx context.Context, projectID string, enumIDs ...string) error {
+	if len(enumIDs) == 0 {
+		enumIDs = standardEnumIDs
+	}
+
+	types, err := p.EnumOptions(ctx, projectID, "", "type")
+	if err != nil {
+		return err
+	}
+	for _, enumID := range enumIDs {
+		if _, err := p.EnumOptions(ctx, projectID, "", enumID); err != nil {
+			return err
+		}
+		if enumID == "type" {
+			continue
+		}
+		for _, t := range types {
+			if _, err := p.EnumOptions(ctx, projectID, t.ID, enumID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EnumLabeler returns labeler of enums in project (and work item type if given).
+// Options which can not be loaded are labeled with their IDs.
+func (p *Polarion) EnumLabeler(projectID, typeID string) model.EnumLabeler {
+	return &enumLabeler{polarion: p, projectID: projectID, typeID: typeID}
+}
+
+type enumLabeler struct {
+	polarion  *Polarion
+	projectID string
+	typeID    string
+}
+
+func (l *enumLabeler) EnumLabel(ctx context.Context, enumID, optionID string) string {
+	option, err := l.polarion.EnumOption(ctx, l.projectID, l.typeID, enumID, optionID)
+	if err != nil || option == nil {
+		return optionID
+	}
+	return option.Label()
+}
+
+func (p *Polarion) cachedEnumOptions(ctx context.Context, key enumKey) ([]EnumValue, error) {
+	if values, ok := p.enums.get(key); ok {
+		return values, nil
+	}
+
+	options, err := p.loadEnumOptions(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]EnumValue, 0, len(options))
+	for _, option := range options {
+		if option != nil {
+			values = append(values, enumValueFromWS(option, key.enumID))
+		}
+	}
+	p.enums.set(key, values)
+	return values, nil
+}
+
+// type specific options of enum, all project options if there are none for type
+func (p *Polarion) loadEnumOptions(ctx context.Context, key enumKey) ([]*enumOption, error) {
+	if key.typeID != "" {
+		var req any
+		if key.byKey {
+			req = &tracker_ws.GetEnumOptionsForKeyWithControl{
+				ProjectID:    key.projectID,
+				Key:          key.enumID,
+				ControlValue: key.typeID,
+			}
+		} else {
+			req = &tracker_ws.GetEnumOptionsForIdWithControl{
+				ProjectID:    key.projectID,
+				EnumID:       key.enumID,
+				ControlValue: key.typeID,
+			}
+		}
+		resp := &enumOptionsResponse{}
+		if err := p.TrackerClient.CallContext(ctx, "''", req, resp); err != nil {
+			return nil, fmt.Errorf(
+				"failed to get '%s' options of '%s' in project '%s': %v", key.enumID, key.typeID, key.projectID, err,
+			)
+		}
+		if len(resp.Options) > 0 {
+			return resp.Options, nil
+		}
+	}
+
+	if key.byKey {
+		req := tracker_ws.GetAllEnumOptionsForKey{
+			ProjectID: key.projectID,
+			Key:       key.enumID,
+		}
+		resp := &enumOptionsResponse{}
+		if err := p.TrackerClient.CallContext(ctx, "''", &req, resp); err != nil {
+			return nil, fmt.Errorf("failed to get options of field '%s' in project '%s': %v", key.enumID, key.projectID, err)
+		}
+		return resp.Options, nil
+	}
+
+	req := tracker_ws.GetAllEnumOptionsForId{
+		ProjectID: key.projectID,
+		EnumID:    key.enumID,
+	}
+	resp := &enumOptionsResponse{}
+	if err := p.TrackerClient.CallContext(ctx, "''", &req, resp); err != nil {
+		return nil, fmt.Errorf("failed to get '%s' options in project '%s': %v", key.enumID, key.projectID, err)
+	}
+	return resp.Options, nil
+}
@@ -0,0 +1,60 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func TestEnumOptionsXML(t *testing.T) {
+	response := `<getAllEnumOptionsForIdResponse xmlns="http://ws.polarion.com/TrackerWebService-impl">
+		<getAllEnumOptionsForIdReturn xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="ns1:PriorityOpt">
+			<enumId xmlns="">priority</enumId>
+			<id xmlns="">high</id>
+			<name xmlns="">High</name>
+			<properties xmlns="">
+				<property xmlns="http://ws.polarion.com/types"><key xmlns="">color</key><value xmlns="">#f00</value></property>
+			</properties>
+			<float xmlns="">75.5</float>
+		</getAllEnumOptionsForIdReturn>
+		<getAllEnumOptionsForIdReturn xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="ns1:PriorityOpt">
+			<enumId xmlns="">priority</enumId>
+			<id xmlns="">50.0</id>
+			<name xmlns="">Medium</name>
+		</getAllEnumOptionsForIdReturn>
+	</getAllEnumOptionsForIdResponse>`
+
+	var resp enumOptionsResponse
+	if err := xml.Unmarshal([]byte(response), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id       string
+		name     string
+		color    string
+		priority float64
+	}{
+		{"high", "High", "#f00", 75.5},
+		// option without float element falls back to its ID
+		{"50.0", "Medium", "", 50},
+	}
+	if len(resp.Options) != len(tests) {
+		t.Fatalf("got %d options", len(resp.Options))
+	}
+	for i, tt := range tests {
+		value := enumValueFromWS(resp.Options[i], "priority")
+		if value.ID != tt.id || value.Name != tt.name || value.Color != tt.color || value.Priority != tt.priority {
+			t.Errorf("option %d = %+v, want %s %s color %q priority %v", i, value, tt.id, tt.name, tt.color, tt.priority)
+		}
+	}
+}
+
+func TestEnumValueWithoutPriority(t *testing.T) {
+	id := "7"
+	value := enumValueFromWS(&enumOption{EnumOption: tracker_ws.EnumOption{Id: &id}}, "status")
+	if value.Priority != 0 {
+		t.Errorf("priority of status option = %v, want 0", value.Priority)
+	}
+}
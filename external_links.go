@@ -0,0 +1,322 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+type ExternalLinkKind string
+
+const (
+	// link to web page (WorkItem.Hyperlinks)
+	ExternalHyperlink ExternalLinkKind = "hyperlink"
+	// link to work item in other Polarion server (WorkItem.ExternallyLinkedWorkItems)
+	ExternalWorkItem ExternalLinkKind = "workitem"
+	// link to OSLC resource (WorkItem.LinkedOslcResources)
+	ExternalOslc ExternalLinkKind = "oslc"
+)
+
+// enums of roles by link kind, OSLC roles are defined by remote providers and are not validated
+var externalLinkRoleEnums = map[ExternalLinkKind]string{
+	ExternalHyperlink: "hyperlink-role",
+	ExternalWorkItem:  linkRoleEnumID,
+}
+
+// ExternalLink is link from work item to resource outside of Polarion project
+type ExternalLink struct {
+	Kind ExternalLinkKind
+	URL  string
+	Role string
+
+	// label of OSLC resource
+	Label string
+}
+
+// key identifying link, URL must be normalized
+func (l ExternalLink) key() string {
+	return string(l.Kind) + " " + l.Role + " " + l.URL
+}
+
+// ExternalLinkChanges lists links changed by SyncExternalLinks
+type ExternalLinkChanges struct {
+	Added   []ExternalLink
+	Removed []ExternalLink
+
+	// OSLC links not in desired links, they can not be removed through web services and are kept
+	Skipped []ExternalLink
+}
+
+// NormalizeURL returns absolute URL with lowercase scheme and host and without default port,
+// so the same resource is not linked twice under different spellings
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL '%s': %v", raw, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("URL '%s' is not absolute", raw)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+	if u.Path == "/" {
+		u.Path = ""
+	}
+	return u.String(), nil
+}
+
+// ExternalLinks lists hyperlinks, externally linked work items and OSLC resources of work item
+func (p *Polarion) ExternalLinks(ctx context.Context, uri *tracker_ws.SubterraURI) ([]ExternalLink, error) {
+	wi, err := p.externalLinksOf(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return externalLinksFromWS(wi), nil
+}
+
+// AddExternalLink validates role, normalizes URL and adds link if work item does not have it yet
+func (p *Polarion) AddExternalLink(ctx context.Context, uri *tracker_ws.SubterraURI, link ExternalLink) error {
+	wi, err := p.externalLinksOf(ctx, uri)
+	if err != nil {
+		return err
+	}
+	if link, err = p.validateExternalLink(ctx, projectIDOf(wi.Project), link); err != nil {
+		return err
+	}
+	for _, existing := range externalLinksFromWS(wi) {
+		if normalizedLink(existing).key() == link.key() {
+			return nil
+		}
+	}
+	return p.addExternalLink(ctx, uri, link)
+}
+
+// RemoveExternalLink removes link, links which work item does not have are ignored.
+// Hyperlinks are removed with all roles, as Polarion removes them by URL only.
+func (p *Polarion) RemoveExternalLink(ctx context.Context, uri *tracker_ws.SubterraURI, link ExternalLink) error {
+	normalized, err := NormalizeURL(link.URL)
+	if err != nil {
+		return err
+	}
+	link.URL = normalized
+
+	wi, err := p.externalLinksOf(ctx, uri)
+	if err != nil {
+		return err
+	}
+	for _, existing := range externalLinksFromWS(wi) {
+		if normalizedLink(existing).key() != link.key() {
+			continue
+		}
+		// stored spelling of URL is needed for removal
+		if err := p.removeExternalLink(ctx, uri, existing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceExternalLink removes old link and adds new one
+func (p *Polarion) ReplaceExternalLink(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	old, replacement ExternalLink,
+) error {
+	wi, err := p.externalLinksOf(ctx, uri)
+	if err != nil {
+		return err
+	}
+	if replacement, err = p.validateExternalLink(ctx, projectIDOf(wi.Project), replacement); err != nil {
+		return err
+	}
+	if err := p.RemoveExternalLink(ctx, uri, old); err != nil {
+		return err
+	}
+	return p.addExternalLink(ctx, uri, replacement)
+}
+
+// SyncExternalLinks makes external links of work item equal to desired links,
+// only missing links are added and only extra links are removed, so repeated calls
+// change nothing. All links are validated before any change is made.
+// OSLC links which are not desired are left in place and reported as skipped.
+func (p *Polarion) SyncExternalLinks(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	desired []ExternalLink,
+) (*ExternalLinkChanges, error) {
+	wi, err := p.externalLinksOf(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]ExternalLink{}
+	for _, link := range desired {
+		if link, err = p.validateExternalLink(ctx, projectIDOf(wi.Project), link); err != nil {
+			return nil, err
+		}
+		wanted[link.key()] = link
+	}
+
+	changes := externalLinkChanges(externalLinksFromWS(wi), wanted)
+	for _, link := range changes.Removed {
+		if err := p.removeExternalLink(ctx, uri, link); err != nil {
+			return nil, err
+		}
+	}
+	for _, link := range changes.Added {
+		if err := p.addExternalLink(ctx, uri, link); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// diff of current links of work item and wanted links by their keys
+func externalLinkChanges(current []ExternalLink, wanted map[string]ExternalLink) *ExternalLinkChanges {
+	changes := &ExternalLinkChanges{}
+	existing := map[string]struct{}{}
+	// hyperlinks are removed by URL with all their roles
+	removedURLs := map[string]struct{}{}
+	for _, link := range current {
+		normalized := normalizedLink(link)
+		existing[normalized.key()] = struct{}{}
+		if _, ok := wanted[normalized.key()]; ok {
+			continue
+		}
+		switch link.Kind {
+		case ExternalOslc:
+			changes.Skipped = append(changes.Skipped, link)
+			continue
+		case ExternalHyperlink:
+			removedURLs[normalized.URL] = struct{}{}
+		}
+		changes.Removed = append(changes.Removed, link)
+	}
+	for _, key := range sortedKeys(wanted) {
+		link := wanted[key]
+		_, exists := existing[key]
+		_, removed := removedURLs[link.URL]
+		if !exists || (link.Kind == ExternalHyperlink && removed) {
+			changes.Added = append(changes.Added, link)
+		}
+	}
+	return changes
+}
+
+// link with normalized URL, links with invalid stored URLs are kept as they are
+func normalizedLink(link ExternalLink) ExternalLink {
+	if normalized, err := NormalizeURL(link.URL); err == nil {
+		link.URL = normalized
+	}
+	return link
+}
+
+func (p *Polarion) externalLinksOf(ctx context.Context, uri *tracker_ws.SubterraURI) (*tracker_ws.WorkItem, error) {
+	return p.workItemFields(ctx, uri, "project", "hyperlinks", "externallyLinkedWorkItems", "linkedOslcResources")
+}
+
+func externalLinksFromWS(wi *tracker_ws.WorkItem) []ExternalLink {
+	var links []ExternalLink
+	if wi.Hyperlinks != nil {
+		for _, l := range wi.Hyperlinks.Hyperlink {
+			if l != nil {
+				links = append(links, ExternalLink{Kind: ExternalHyperlink, URL: l.Uri, Role: model.EnumID(l.Role)})
+			}
+		}
+	}
+	if wi.ExternallyLinkedWorkItems != nil {
+		for _, l := range wi.ExternallyLinkedWorkItems.ExternallyLinkedWorkItem {
+			if l != nil {
+				links = append(links, ExternalLink{Kind: ExternalWorkItem, URL: l.WorkItemURI, Role: model.EnumID(l.Role)})
+			}
+		}
+	}
+	if wi.LinkedOslcResources != nil {
+		for _, l := range wi.LinkedOslcResources.LinkedOslcResource {
+			if l != nil {
+				links = append(links, ExternalLink{Kind: ExternalOslc, URL: l.Uri, Role: model.EnumID(l.Role), Label: l.Label})
+			}
+		}
+	}
+	return links
+}
+
+// returns link with normalized URL
+func (p *Polarion) validateExternalLink(ctx context.Context, projectID string, link ExternalLink) (ExternalLink, error) {
+	normalized, err := NormalizeURL(link.URL)
+	if err != nil {
+		return link, err
+	}
+	link.URL = normalized
+
+	if link.Role == "" {
+		return link, fmt.Errorf("role of %s link %s is required", link.Kind, link.URL)
+	}
+	if link.Kind == ExternalOslc {
+		return link, nil
+	}
+	enumID, ok := externalLinkRoleEnums[link.Kind]
+	if !ok {
+		return link, fmt.Errorf("unknown external link kind '%s'", link.Kind)
+	}
+
+	return link, p.checkEnumOption(ctx, projectID, enumID, string(link.Kind)+" role", link.Role)
+}
+
+func (p *Polarion) addExternalLink(ctx context.Context, uri *tracker_ws.SubterraURI, link ExternalLink) error {
+	var err error
+	switch link.Kind {
+	case ExternalHyperlink:
+		req := tracker_ws.AddHyperlink{WorkitemURI: uri, Url: link.URL, Role: model.NewEnumID(link.Role)}
+		_, err = p.TrackerWS.AddHyperlinkContext(ctx, &req)
+	case ExternalWorkItem:
+		req := tracker_ws.AddExternallyLinkedItem{WorkitemURI: uri, LinkedExternalWorkitemURI: link.URL, Role: model.NewEnumID(link.Role)}
+		_, err = p.TrackerWS.AddExternallyLinkedItemContext(ctx, &req)
+	case ExternalOslc:
+		req := tracker_ws.AddLinkedOslcItem{WorkitemURI: uri, LinkedOslcItemURI: link.URL, Role: model.NewEnumID(link.Role), Label: link.Label}
+		_, err = p.TrackerWS.AddLinkedOslcItemContext(ctx, &req)
+	default:
+		return fmt.Errorf("unknown external link kind '%s'", link.Kind)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to add %s link %s to work item %s: %v", link.Kind, link.URL, model.URI(uri), err)
+	}
+	return nil
+}
+
+func (p *Polarion) removeExternalLink(ctx context.Context, uri *tracker_ws.SubterraURI, link ExternalLink) error {
+	var err error
+	switch link.Kind {
+	case ExternalHyperlink:
+		req := tracker_ws.RemoveHyperlink{WorkitemURI: uri, Url: link.URL}
+		_, err = p.TrackerWS.RemoveHyperlinkContext(ctx, &req)
+	case ExternalWorkItem:
+		req := tracker_ws.RemoveExternallyLinkedItem{WorkitemURI: uri, LinkedExternalWorkitemURI: link.URL, Role: model.NewEnumID(link.Role)}
+		_, err = p.TrackerWS.RemoveExternallyLinkedItemContext(ctx, &req)
+	case ExternalOslc:
+		return fmt.Errorf("OSLC links can not be removed through web services")
+	default:
+		return fmt.Errorf("unknown external link kind '%s'", link.Kind)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to remove %s link %s from work item %s: %v", link.Kind, link.URL, model.URI(uri), err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package polarion_wsdl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "HTTP://Example.COM:80/", want: "http://example.com"},
+		{raw: "https://example.com:443/a?b=1", want: "https://example.com/a?b=1"},
+		{raw: " https://example.com:8443/x ", want: "https://example.com:8443/x"},
+		// path is case sensitive
+		{raw: "https://Example.com/Path", want: "https://example.com/Path"},
+		{raw: "http://[::1]:80/", want: "http://[::1]"},
+		{raw: "http://[::1]:8080/a", want: "http://[::1]:8080/a"},
+		{raw: "/relative/path", wantErr: true},
+		{raw: "example.com", wantErr: true},
+		{raw: "http://example.com/%zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := NormalizeURL(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizedLinkKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b ExternalLink
+		same bool
+	}{
+		{
+			"spelling of URL",
+			ExternalLink{Kind: ExternalHyperlink, URL: "HTTPS://Example.com:443/", Role: "ref_ext"},
+			ExternalLink{Kind: ExternalHyperlink, URL: "https://example.com", Role: "ref_ext"},
+			true,
+		},
+		{
+			"role",
+			ExternalLink{Kind: ExternalHyperlink, URL: "https://example.com", Role: "ref_ext"},
+			ExternalLink{Kind: ExternalHyperlink, URL: "https://example.com", Role: "ref_int"},
+			false,
+		},
+		{
+			"kind",
+			ExternalLink{Kind: ExternalHyperlink, URL: "https://example.com", Role: "relates_to"},
+			ExternalLink{Kind: ExternalOslc, URL: "https://example.com", Role: "relates_to"},
+			false,
+		},
+		{
+			"invalid URL is kept",
+			ExternalLink{Kind: ExternalHyperlink, URL: "not a url", Role: "ref_ext"},
+			ExternalLink{Kind: ExternalHyperlink, URL: "not a url", Role: "ref_ext"},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := normalizedLink(tt.a).key(), normalizedLink(tt.b).key()
+			if (a == b) != tt.same {
+				t.Errorf("keys %q and %q, want same %v", a, b, tt.same)
+			}
+		})
+	}
+}
+
+func TestExternalLinkChanges(t *testing.T) {
+	page := ExternalLink{Kind: ExternalHyperlink, URL: "https://example.com/page", Role: "ref_ext"}
+	pageInternal := ExternalLink{Kind: ExternalHyperlink, URL: "https://example.com/page", Role: "ref_int"}
+	remote := ExternalLink{Kind: ExternalWorkItem, URL: "https://other.example.com/polarion/#/wi/X-1", Role: "relates_to"}
+	oslc := ExternalLink{Kind: ExternalOslc, URL: "https://jira.example.com/browse/J-1", Role: "implements", Label: "J-1"}
+
+	wanted := func(links ...ExternalLink) map[string]ExternalLink {
+		m := map[string]ExternalLink{}
+		for _, link := range links {
+			m[link.key()] = link
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		current []ExternalLink
+		wanted  map[string]ExternalLink
+		want    ExternalLinkChanges
+	}{
+		{
+			name:    "nothing to change",
+			current: []ExternalLink{{Kind: ExternalHyperlink, URL: "HTTPS://EXAMPLE.com:443/page", Role: "ref_ext"}, remote},
+			wanted:  wanted(page, remote),
+		},
+		{
+			name:    "missing links are added",
+			current: []ExternalLink{page},
+			wanted:  wanted(page, remote),
+			want:    ExternalLinkChanges{Added: []ExternalLink{remote}},
+		},
+		{
+			name:    "extra links are removed",
+			current: []ExternalLink{page, remote},
+			wanted:  wanted(page),
+			want:    ExternalLinkChanges{Removed: []ExternalLink{remote}},
+		},
+		{
+			// removal of hyperlink removes all its roles
+			name:    "hyperlink role removed",
+			current: []ExternalLink{page, pageInternal},
+			wanted:  wanted(page),
+			want:    ExternalLinkChanges{Added: []ExternalLink{page}, Removed: []ExternalLink{pageInternal}},
+		},
+		{
+			name:    "unmanaged OSLC link is skipped",
+			current: []ExternalLink{oslc, remote},
+			wanted:  wanted(page),
+			want:    ExternalLinkChanges{Added: []ExternalLink{page}, Removed: []ExternalLink{remote}, Skipped: []ExternalLink{oslc}},
+		},
+		{
+			name:    "desired OSLC link is kept",
+			current: []ExternalLink{oslc},
+			wanted:  wanted(oslc),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := externalLinkChanges(tt.current, tt.wanted)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("externalLinkChanges() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/test_ws"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// prefix used by Polarion to request single custom field in fields list,
+// e.g. "customFields.asil"
+const customFieldsPrefix = "customFields."
+
+var projectQueryPattern = regexp.MustCompile(`\bproject\.id:"?([\w.-]+)"?`)
+
+var (
+	workItemFieldNames = xmlFieldNames(reflect.TypeOf(tracker_ws.WorkItem{}))
+	revisionFieldNames = xmlFieldNames(reflect.TypeOf(tracker_ws.Revision{}))
+	testRunFieldNames  = xmlFieldNames(reflect.TypeOf(test_ws.TestRun{}))
+)
+
+// custom field keys by project, loaded with LoadCustomFieldKeys or lazily
+// when "customFields.<key>" entries of work item field lists are validated
+type customFieldKeyCache struct {
+	mu   sync.RWMutex
+	keys map[string]map[string]struct{}
+
+	// projects whose keys of all work item types were loaded
+	complete map[string]bool
+}
+
+func (c *customFieldKeyCache) add(projectID string, keys []string, complete bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys == nil {
+		c.keys = map[string]map[string]struct{}{}
+		c.complete = map[string]bool{}
+	}
+	if c.keys[projectID] == nil {
+		c.keys[projectID] = make(map[string]struct{}, len(keys))
+	}
+	for _, key := range keys {
+		c.keys[projectID][key] = struct{}{}
+	}
+	if complete {
+		c.complete[projectID] = true
+	}
+}
+
+func (c *customFieldKeyCache) isComplete(projectID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.complete[projectID]
+}
+
+// keys of project, keys of all loaded projects for empty project ID,
+// returns nil if no keys were loaded yet
+func (c *customFieldKeyCache) snapshot(projectID string) map[string]struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if projectID != "" {
+		return c.keys[projectID]
+	}
+
+	var all map[string]struct{}
+	for _, keys := range c.keys {
+		if all == nil {
+			all = map[string]struct{}{}
+		}
+		for key := range keys {
+			all[key] = struct{}{}
+		}
+	}
+	return all
+}
+
+// struct field index by xml element/attribute name
+func xmlFieldIndex(t reflect.Type) map[string]int {
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("xml")
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" || strings.Contains(name, " ") {
+			continue
+		}
+		index[name] = i
+	}
+	return index
+}
+
+// xml element/attribute names of struct fields
+func xmlFieldNames(t reflect.Type) map[string]struct{} {
+	index := xmlFieldIndex(t)
+	names := make(map[string]struct{}, len(index))
+	for name := range index {
+		names[name] = struct{}{}
+	}
+	return names
+}
+
+// LoadCustomFieldKeys fetches custom field keys defined for given work item types
+// (all types if none provided) of the project and registers them for field list validation.
+// Keys of all types are also loaded automatically when field list of work items
+// with known project is validated, see ValidateWorkItemFields.
+func (p *Polarion) LoadCustomFieldKeys(ctx context.Context, projectID string, typeIDs ...string) error {
+	complete := len(typeIDs) == 0
+	if complete {
+		typeIDs = []string{""}
+	}
+
+	for _, typeID := range typeIDs {
+		req := tracker_ws.GetDefinedCustomFieldKeys{
+			ProjectID: projectID,
+			TypeID:    typeID,
+		}
+		resp, err := p.TrackerWS.GetDefinedCustomFieldKeysContext(ctx, &req)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to get custom field keys for project '%s' type '%s': %v",
+				projectID, typeID, err,
+			)
+		}
+		p.customFieldKeys.add(projectID, resp.GetDefinedCustomFieldKeysReturn, complete)
+	}
+
+	return nil
+}
+
+// ValidateWorkItemFields checks that every field is a known WorkItem field name
+// or a custom field key of the project. Custom field keys of the project are loaded
+// on first use, with empty project ID they are checked against keys of all loaded
+// projects and passed to Polarion unchecked if no keys were loaded yet.
+func (p *Polarion) ValidateWorkItemFields(ctx context.Context, projectID string, fields []string) error {
+	if projectID != "" && !p.customFieldKeys.isComplete(projectID) && slices.ContainsFunc(fields, isCustomField) {
+		if err := p.LoadCustomFieldKeys(ctx, projectID); err != nil {
+			return err
+		}
+	}
+	return validateFields("work item", fields, workItemFieldNames, p.customFieldKeys.snapshot(projectID), true)
+}
+
+func isCustomField(field string) bool {
+	return strings.HasPrefix(field, customFieldsPrefix)
+}
+
+// project of work item query, empty if query does not select exactly one project,
+// e.g. "project.id:demo AND type:task" -> "demo"
+func queryProjectID(query string) string {
+	projectID := ""
+	for _, match := range projectQueryPattern.FindAllStringSubmatch(query, -1) {
+		if projectID != "" && match[1] != projectID {
+			return ""
+		}
+		projectID = match[1]
+	}
+	return projectID
+}
+
+// project of work item URI, e.g. "subterra:data-service:objects:/default/demo${WorkItem}DEMO-1" -> "demo"
+func uriProjectID(uri *tracker_ws.SubterraURI) string {
+	path, _, ok := strings.Cut(model.URI(uri), "${WorkItem}")
+	if !ok {
+		return ""
+	}
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func validateFields(
+	kind string,
+	fields []string,
+	known map[string]struct{},
+	customKeys map[string]struct{},
+	allowCustom bool,
+) error {
+	for _, field := range fields {
+		if _, ok := known[field]; ok {
+			continue
+		}
+
+		if allowCustom && strings.HasPrefix(field, customFieldsPrefix) {
+			key := strings.TrimPrefix(field, customFieldsPrefix)
+			if customKeys == nil {
+				continue
+			}
+			if _, ok := customKeys[key]; ok {
+				continue
+			}
+			return fmt.Errorf(
+				"unknown %s custom field '%s'%s",
+				kind, key, suggestion(key, customKeys),
+			)
+		}
+
+		return fmt.Errorf("unknown %s field '%s'%s", kind, field, suggestion(field, known))
+	}
+
+	return nil
+}
+
+// error for ID which is not among IDs defined in project, nil if it is
+func checkProjectID(what, id, projectID string, ids []string) error {
+	known := make(map[string]struct{}, len(ids))
+	for _, candidate := range ids {
+		if candidate == id {
+			return nil
+		}
+		known[candidate] = struct{}{}
+	}
+	return fmt.Errorf("unknown %s '%s' in project '%s'%s", what, id, projectID, suggestion(id, known))
+}
+
+// hint with the closest known name, empty if nothing is close enough
+func suggestion(name string, known map[string]struct{}) string {
+	candidates := make([]string, 0, len(known))
+	for candidate := range known {
+		candidates = append(candidates, candidate)
+	}
+	// deterministic result when several candidates are equally close
+	sort.Strings(candidates)
+
+	best, bestDistance := "", len(name)/2+1
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean '%s'?)", best)
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,178 @@
+package polarion_wsdl
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"status", "status", 0},
+		{"staus", "status", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"čaj", "caj", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestion(t *testing.T) {
+	known := map[string]struct{}{
+		"status":   {},
+		"severity": {},
+		"title":    {},
+		"dueDate":  {},
+	}
+
+	tests := []struct {
+		name  string
+		known map[string]struct{}
+		want  string
+	}{
+		{"staus", known, " (did you mean 'status'?)"},
+		{"Title", known, " (did you mean 'title'?)"},
+		{"duedate", known, " (did you mean 'dueDate'?)"},
+		{"assignee", known, ""},
+		{"x", known, ""},
+		{"status", nil, ""},
+		// equally close candidates, the first in sorted order wins
+		{"ab", map[string]struct{}{"bb": {}, "aa": {}}, " (did you mean 'aa'?)"},
+	}
+
+	for _, tt := range tests {
+		if got := suggestion(tt.name, tt.known); got != tt.want {
+			t.Errorf("suggestion(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckProjectID(t *testing.T) {
+	ids := []string{"en", "de", "cs"}
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"de", ""},
+		{"DE", "unknown language 'DE' in project 'demo' (did you mean 'de'?)"},
+		{"fr", "unknown language 'fr' in project 'demo'"},
+	}
+	for _, tt := range tests {
+		err := checkProjectID("language", tt.id, "demo", ids)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("checkProjectID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	customKeys := map[string]struct{}{"asil": {}, "externalId": {}}
+
+	tests := []struct {
+		name       string
+		fields     []string
+		customKeys map[string]struct{}
+		wantErr    string
+	}{
+		{"known fields", []string{"id", "title", "linkedWorkItems"}, nil, ""},
+		{"unknown field", []string{"id", "titel"}, nil, "unknown work item field 'titel' (did you mean 'title'?)"},
+		{"custom fields unchecked without keys", []string{"customFields.anything"}, nil, ""},
+		{"known custom field", []string{"customFields.asil"}, customKeys, ""},
+		{"unknown custom field", []string{"customFields.asl"}, customKeys, "unknown work item custom field 'asl' (did you mean 'asil'?)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFields("work item", tt.fields, workItemFieldNames, tt.customKeys, true)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomFieldKeyCache(t *testing.T) {
+	var cache customFieldKeyCache
+	if keys := cache.snapshot(""); keys != nil {
+		t.Fatalf("empty cache returned keys %v", keys)
+	}
+
+	cache.add("a", []string{"asil"}, false)
+	cache.add("b", []string{"externalId"}, true)
+
+	tests := []struct {
+		projectID string
+		want      []string
+		complete  bool
+	}{
+		{"a", []string{"asil"}, false},
+		{"b", []string{"externalId"}, true},
+		{"", []string{"asil", "externalId"}, false},
+		{"c", nil, false},
+	}
+
+	for _, tt := range tests {
+		keys := cache.snapshot(tt.projectID)
+		if got := sortedKeys(keys); !slices.Equal(got, tt.want) {
+			t.Errorf("snapshot(%q) = %v, want %v", tt.projectID, got, tt.want)
+		}
+		if tt.projectID != "" && cache.isComplete(tt.projectID) != tt.complete {
+			t.Errorf("isComplete(%q) = %v, want %v", tt.projectID, !tt.complete, tt.complete)
+		}
+	}
+}
+
+func TestQueryProjectID(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"project.id:demo AND type:task", "demo"},
+		{`project.id:"demo" AND status:open`, "demo"},
+		{"type:task", ""},
+		{"project.id:demo OR project.id:other", ""},
+		{"project.id:demo AND NOT (project.id:demo AND status:closed)", "demo"},
+	}
+
+	for _, tt := range tests {
+		if got := queryProjectID(tt.query); got != tt.want {
+			t.Errorf("queryProjectID(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestURIProjectID(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"subterra:data-service:objects:/default/demo${WorkItem}DEMO-1", "demo"},
+		{"subterra:data-service:objects:/default/demo${Project}demo", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := uriProjectID(model.NewURI(tt.uri)); got != tt.want {
+			t.Errorf("uriProjectID(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+}
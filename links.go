@@ -0,0 +1,159 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// enum of work item link roles
+const linkRoleEnumID = "workitem-link-role"
+
+type LinkDirection string
+
+const (
+	// link stored in the work item itself
+	LinkOutgoing LinkDirection = "outgoing"
+	// link from other work item to this one
+	LinkBack LinkDirection = "back"
+)
+
+type LinkOptions struct {
+	// revision of linked work item the link is pinned to, empty for HEAD
+	Revision string
+	Suspect  bool
+}
+
+// WorkItemLink is outgoing or back link of work item
+type WorkItemLink struct {
+	Direction LinkDirection
+
+	// URI of the other work item
+	URI  string
+	Role string
+
+	// name of role as seen from this work item (opposite name for back links)
+	RoleName string
+	Revision string
+	Suspect  bool
+}
+
+// LinkRoles returns link roles of project
+func (p *Polarion) LinkRoles(ctx context.Context, projectID string) ([]EnumValue, error) {
+	return p.EnumOptions(ctx, projectID, "", linkRoleEnumID)
+}
+
+// ValidateLinkRole returns error if role is not link role of project
+func (p *Polarion) ValidateLinkRole(ctx context.Context, projectID, role string) error {
+	return p.checkEnumOption(ctx, projectID, linkRoleEnumID, "link role", role)
+}
+
+// Link links work item to other work item with role of its project
+func (p *Polarion) Link(
+	ctx context.Context,
+	from, to *tracker_ws.SubterraURI,
+	role string,
+	opts *LinkOptions,
+) error {
+	if from == nil || to == nil {
+		return fmt.Errorf("both work item URIs are required for link")
+	}
+	if err := p.validateWorkItemLinkRole(ctx, from, role); err != nil {
+		return err
+	}
+
+	link := model.Link{URI: model.URI(to), Role: role}
+	if opts != nil {
+		link.Revision, link.Suspect = opts.Revision, opts.Suspect
+	}
+	return p.addLink(ctx, from, link)
+}
+
+// Unlink removes link with role from work item to other work item.
+// Role is not validated, so links with roles removed from project can be removed too.
+func (p *Polarion) Unlink(ctx context.Context, from, to *tracker_ws.SubterraURI, role string) error {
+	if from == nil || to == nil {
+		return fmt.Errorf("both work item URIs are required for unlink")
+	}
+	return p.removeLink(ctx, from, model.Link{URI: model.URI(to), Role: role})
+}
+
+// AutoSuspect marks links to work item as suspect, as Polarion does on change when configured
+func (p *Polarion) AutoSuspect(ctx context.Context, uri *tracker_ws.SubterraURI) error {
+	if _, err := p.TrackerWS.DoAutoSuspectContext(ctx, &tracker_ws.DoAutoSuspect{WorkitemURI: uri}); err != nil {
+		return fmt.Errorf("failed to auto suspect links of work item %s: %v", model.URI(uri), err)
+	}
+	return nil
+}
+
+// ListLinks returns outgoing links of work item followed by its back links
+func (p *Polarion) ListLinks(ctx context.Context, uri *tracker_ws.SubterraURI) ([]WorkItemLink, error) {
+	wi, err := p.workItemFields(ctx, uri, "project", "linkedWorkItems")
+	if err != nil {
+		return nil, err
+	}
+
+	back, err := p.TrackerWS.GetBackLinkedWorkitemsContext(ctx, &tracker_ws.GetBackLinkedWorkitems{WorkitemURI: uri})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get back links of work item %s: %v", model.URI(uri), err)
+	}
+
+	roles, err := p.LinkRoles(ctx, projectIDOf(wi.Project))
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]EnumValue{}
+	for _, role := range roles {
+		names[role.ID] = role
+	}
+
+	var links []WorkItemLink
+	for _, link := range model.WorkItemFromWS(wi).Links {
+		links = append(links, WorkItemLink{
+			Direction: LinkOutgoing,
+			URI:       link.URI,
+			Role:      link.Role,
+			RoleName:  linkRoleName(names, link.Role, LinkOutgoing),
+			Revision:  link.Revision,
+			Suspect:   link.Suspect,
+		})
+	}
+	for _, l := range back.GetBackLinkedWorkitemsReturn {
+		if l == nil {
+			continue
+		}
+		link := model.LinkFromWS(l)
+		links = append(links, WorkItemLink{
+			Direction: LinkBack,
+			URI:       link.URI,
+			Role:      link.Role,
+			RoleName:  linkRoleName(names, link.Role, LinkBack),
+			Revision:  link.Revision,
+			Suspect:   link.Suspect,
+		})
+	}
+	return links, nil
+}
+
+// validates role against project of work item
+func (p *Polarion) validateWorkItemLinkRole(ctx context.Context, uri *tracker_ws.SubterraURI, role string) error {
+	wi, err := p.workItemFields(ctx, uri, "project")
+	if err != nil {
+		return err
+	}
+	return p.ValidateLinkRole(ctx, projectIDOf(wi.Project), role)
+}
+
+// role name for direction, ID for roles not in project enum
+func linkRoleName(roles map[string]EnumValue, role string, direction LinkDirection) string {
+	option, ok := roles[role]
+	if !ok {
+		return role
+	}
+	if opposite := option.Properties["oppositeName"]; direction == LinkBack && opposite != "" {
+		return opposite
+	}
+	return option.Label()
+}
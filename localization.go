@@ -0,0 +1,495 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// localizable work item fields
+const (
+	TranslateTitle       = "title"
+	TranslateDescription = "description"
+)
+
+// content type of descriptions whose type is not known, as Polarion descriptions are HTML
+const defaultTranslationType = model.TextHTML
+
+type Language struct {
+	ID      string
+	Label   string
+	Default bool
+}
+
+// Localization is title and description of work item in one language
+type Localization struct {
+	Title       string
+	Description *model.Text
+}
+
+// Localizations are keyed by language ID
+type Localizations map[string]Localization
+
+// TranslationUnit is one work item field with source text and its translation
+type TranslationUnit struct {
+	URI   string `json:"uri"`
+	ID    string `json:"id"`
+	Field string `json:"field"`
+
+	// content type of description, empty for title
+	Type   string `json:"type,omitempty"`
+	Source string `json:"source"`
+
+	// empty if field is not translated yet
+	Target string `json:"target,omitempty"`
+}
+
+// TranslationBundle holds fields of work items for translation from source to target language
+type TranslationBundle struct {
+	SourceLanguage string            `json:"sourceLanguage"`
+	TargetLanguage string            `json:"targetLanguage"`
+	Units          []TranslationUnit `json:"units"`
+}
+
+// Languages returns languages defined in project, default language is first
+func (p *Polarion) Languages(ctx context.Context, projectID string) ([]Language, error) {
+	req := tracker_ws.GetLanguageDefinitions{
+		ProjectId: projectID,
+	}
+	resp, err := p.TrackerWS.GetLanguageDefinitionsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get languages of project '%s': %v", projectID, err)
+	}
+
+	defaultReq := tracker_ws.GetDefaultLanguageDefinition{
+		ProjectId: projectID,
+	}
+	defaultResp, err := p.TrackerWS.GetDefaultLanguageDefinitionContext(ctx, &defaultReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default language of project '%s': %v", projectID, err)
+	}
+	defaultLanguage := languageFromWS(defaultResp.GetDefaultLanguageDefinitionReturn)
+
+	var languages []Language
+	for _, definition := range resp.GetLanguageDefinitionsReturn {
+		language := languageFromWS(definition)
+		if language.ID == "" {
+			continue
+		}
+		if language.ID == defaultLanguage.ID {
+			language.Default = true
+			languages = append([]Language{language}, languages...)
+			continue
+		}
+		languages = append(languages, language)
+	}
+	return languages, nil
+}
+
+func languageFromWS(definition *tracker_ws.LanguageDefinition) Language {
+	var language Language
+	if definition == nil {
+		return language
+	}
+	if definition.Id != nil {
+		language.ID = *definition.Id
+	}
+	if definition.Label != nil {
+		language.Label = *definition.Label
+	}
+	return language
+}
+
+// Localizations returns title and description of work item in all languages of its project,
+// it takes three requests plus two per language
+func (p *Polarion) Localizations(ctx context.Context, workItemURI *tracker_ws.SubterraURI) (Localizations, error) {
+	wi, err := p.workItemFields(ctx, workItemURI, "project")
+	if err != nil {
+		return nil, err
+	}
+	languages, err := p.Languages(ctx, projectIDOf(wi.Project))
+	if err != nil {
+		return nil, err
+	}
+
+	localizations := Localizations{}
+	for _, language := range languages {
+		localization, err := p.localization(ctx, workItemURI, language.ID)
+		if err != nil {
+			return nil, err
+		}
+		localizations[language.ID] = localization
+	}
+	return localizations, nil
+}
+
+func (p *Polarion) localization(ctx context.Context, workItemURI *tracker_ws.SubterraURI, language string) (Localization, error) {
+	titleReq := tracker_ws.GetLocalizedWorkItemTitle{
+		Uri:      workItemURI,
+		Language: language,
+	}
+	titleResp, err := p.TrackerWS.GetLocalizedWorkItemTitleContext(ctx, &titleReq)
+	if err != nil {
+		return Localization{}, fmt.Errorf("failed to get %s title of work item %s: %v", language, model.URI(workItemURI), err)
+	}
+
+	descriptionReq := tracker_ws.GetLocalizedWorkItemDescription{
+		Uri:      workItemURI,
+		Language: language,
+	}
+	descriptionResp, err := p.TrackerWS.GetLocalizedWorkItemDescriptionContext(ctx, &descriptionReq)
+	if err != nil {
+		return Localization{}, fmt.Errorf("failed to get %s description of work item %s: %v", language, model.URI(workItemURI), err)
+	}
+
+	return Localization{
+		Title:       titleResp.GetLocalizedWorkItemTitleReturn,
+		Description: model.TextFromWS(descriptionResp.GetLocalizedWorkItemDescriptionReturn),
+	}, nil
+}
+
+// SetLocalizations writes title and description of work item in given languages,
+// languages must be defined in project. Both fields of each given language are written,
+// empty title and nil description clear the translation. Languages not in localizations
+// are left unchanged, read them with Localizations for update of single field.
+func (p *Polarion) SetLocalizations(ctx context.Context, workItemURI *tracker_ws.SubterraURI, localizations Localizations) error {
+	wi, err := p.workItemFields(ctx, workItemURI, "project")
+	if err != nil {
+		return err
+	}
+	ids := sortedKeys(localizations)
+	if _, err := p.checkLanguage(ctx, projectIDOf(wi.Project), ids...); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		localization := localizations[id]
+		if err := p.setLocalizedTitle(ctx, workItemURI, id, localization.Title); err != nil {
+			return err
+		}
+		description := localization.Description
+		if description == nil {
+			description = &model.Text{Type: defaultTranslationType}
+		}
+		if err := p.setLocalizedDescription(ctx, workItemURI, id, description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Polarion) setLocalizedTitle(ctx context.Context, workItemURI *tracker_ws.SubterraURI, language, title string) error {
+	req := tracker_ws.SetLocalizedWorkItemTitle{
+		Uri:      workItemURI,
+		Language: language,
+		Title:    title,
+	}
+	if _, err := p.TrackerWS.SetLocalizedWorkItemTitleContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to set %s title of work item %s: %v", language, model.URI(workItemURI), err)
+	}
+	return nil
+}
+
+func (p *Polarion) setLocalizedDescription(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	language string,
+	description *model.Text,
+) error {
+	req := tracker_ws.SetLocalizedWorkItemDescription{
+		Uri:         workItemURI,
+		Language:    language,
+		Description: description.ToWS(),
+	}
+	if _, err := p.TrackerWS.SetLocalizedWorkItemDescriptionContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to set %s description of work item %s: %v", language, model.URI(workItemURI), err)
+	}
+	return nil
+}
+
+// ExportTranslations collects titles and descriptions of work items matching query
+// in source language together with their existing translations to target language.
+// Both languages must be defined in projects of all work items. It takes one query,
+// two requests per project for its languages and two requests per work item
+// for each of source and target language which is not the default language of its project,
+// as fields in default language are read by the query.
+func (p *Polarion) ExportTranslations(ctx context.Context, query, sourceLanguage, targetLanguage string) (*TranslationBundle, error) {
+	if sourceLanguage == "" || targetLanguage == "" {
+		return nil, fmt.Errorf("source and target language are required for translation")
+	}
+
+	req := tracker_ws.QueryWorkItems{
+		Query:  query,
+		Sort:   "id",
+		Fields: []string{"id", "title", "description"},
+	}
+	resp, err := p.TrackerWS.QueryWorkItemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work items '%s': %v", query, err)
+	}
+
+	bundle := &TranslationBundle{SourceLanguage: sourceLanguage, TargetLanguage: targetLanguage}
+	// default language by project
+	defaults := map[string]string{}
+	for _, wi := range resp.QueryWorkItemsReturn {
+		if wi == nil || wi.Uri == nil {
+			continue
+		}
+		projectID := uriProjectID(wi.Uri)
+		defaultLanguage, ok := defaults[projectID]
+		if !ok {
+			languages, err := p.checkLanguage(ctx, projectID, sourceLanguage, targetLanguage)
+			if err != nil {
+				return nil, err
+			}
+			for _, language := range languages {
+				if language.Default {
+					defaultLanguage = language.ID
+				}
+			}
+			defaults[projectID] = defaultLanguage
+		}
+
+		source, err := p.exportedLocalization(ctx, wi, sourceLanguage, defaultLanguage)
+		if err != nil {
+			return nil, err
+		}
+		target, err := p.exportedLocalization(ctx, wi, targetLanguage, defaultLanguage)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Units = append(bundle.Units, translationUnits(wi, source, target)...)
+	}
+	return bundle, nil
+}
+
+// localization of queried work item, fields in default language are taken from work item
+func (p *Polarion) exportedLocalization(
+	ctx context.Context,
+	wi *tracker_ws.WorkItem,
+	language string,
+	defaultLanguage string,
+) (Localization, error) {
+	if language == defaultLanguage {
+		return Localization{Title: wi.Title, Description: model.TextFromWS(wi.Description)}, nil
+	}
+	return p.localization(ctx, wi.Uri, language)
+}
+
+// units of fields which have source text
+func translationUnits(wi *tracker_ws.WorkItem, source, target Localization) []TranslationUnit {
+	var units []TranslationUnit
+	uri := model.URI(wi.Uri)
+	if source.Title != "" {
+		units = append(units, TranslationUnit{
+			URI: uri, ID: wi.Id, Field: TranslateTitle, Source: source.Title, Target: target.Title,
+		})
+	}
+	if source.Description != nil && source.Description.Content != "" {
+		unit := TranslationUnit{
+			URI: uri, ID: wi.Id, Field: TranslateDescription,
+			Type: source.Description.Type, Source: source.Description.Content,
+		}
+		if target.Description != nil {
+			unit.Target = target.Description.Content
+		}
+		units = append(units, unit)
+	}
+	return units
+}
+
+// ImportTranslations writes translated units of bundle to target language, one request per unit.
+// Target language must be defined in projects of all units, nothing is written otherwise.
+// Units without target are skipped, descriptions without type are imported as HTML.
+// Failures do not stop remaining units and are returned joined.
+func (p *Polarion) ImportTranslations(ctx context.Context, bundle *TranslationBundle) error {
+	if bundle.TargetLanguage == "" {
+		return fmt.Errorf("target language is required for translation import")
+	}
+	if err := p.validateTranslationLanguage(ctx, bundle); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, unit := range bundle.Units {
+		if unit.Target == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		uri := model.NewURI(unit.URI)
+		var err error
+		switch unit.Field {
+		case TranslateTitle:
+			err = p.setLocalizedTitle(ctx, uri, bundle.TargetLanguage, unit.Target)
+		case TranslateDescription:
+			description := &model.Text{Type: unit.Type, Content: unit.Target}
+			if description.Type == "" {
+				description.Type = defaultTranslationType
+			}
+			err = p.setLocalizedDescription(ctx, uri, bundle.TargetLanguage, description)
+		default:
+			err = fmt.Errorf("unknown translation field '%s' of work item %s", unit.Field, unit.URI)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// target language must be defined in projects of all translated units
+func (p *Polarion) validateTranslationLanguage(ctx context.Context, bundle *TranslationBundle) error {
+	checked := map[string]struct{}{}
+	for _, unit := range bundle.Units {
+		projectID := uriProjectID(model.NewURI(unit.URI))
+		if _, ok := checked[projectID]; ok || unit.Target == "" {
+			continue
+		}
+		checked[projectID] = struct{}{}
+
+		if _, err := p.checkLanguage(ctx, projectID, bundle.TargetLanguage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// languages of project, error if some of ids is not one of them
+func (p *Polarion) checkLanguage(ctx context.Context, projectID string, ids ...string) ([]Language, error) {
+	languages, err := p.Languages(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defined := make([]string, 0, len(languages))
+	for _, language := range languages {
+		defined = append(defined, language.ID)
+	}
+	for _, id := range ids {
+		if err := checkProjectID("language", id, projectID, defined); err != nil {
+			return nil, err
+		}
+	}
+	return languages, nil
+}
+
+func (b *TranslationBundle) JSON() ([]byte, error) {
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// TranslationBundleFromJSON parses bundle written by JSON
+func TranslationBundleFromJSON(data []byte) (*TranslationBundle, error) {
+	bundle := &TranslationBundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse translation bundle: %v", err)
+	}
+	return bundle, nil
+}
+
+// XLIFF 1.2 document, unit ID is work item ID and field, resname is work item URI
+type xliffDocument struct {
+	XMLName xml.Name  `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string    `xml:"version,attr"`
+	File    xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr"`
+	Datatype       string      `xml:"datatype,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+type xliffUnit struct {
+	ID       string `xml:"id,attr"`
+	Resname  string `xml:"resname,attr"`
+	Datatype string `xml:"datatype,attr,omitempty"`
+	Source   string `xml:"source"`
+	Target   string `xml:"target,omitempty"`
+}
+
+// XLIFF writes bundle as XLIFF 1.2 document
+func (b *TranslationBundle) XLIFF(w io.Writer) error {
+	doc := xliffDocument{
+		Version: "1.2",
+		File: xliffFile{
+			Original:       "polarion",
+			SourceLanguage: b.SourceLanguage,
+			TargetLanguage: b.TargetLanguage,
+			Datatype:       "plaintext",
+		},
+	}
+	for _, unit := range b.Units {
+		xu := xliffUnit{
+			ID:      unit.ID + "/" + unit.Field,
+			Resname: unit.URI,
+			Source:  unit.Source,
+			Target:  unit.Target,
+		}
+		switch unit.Type {
+		case model.TextHTML:
+			xu.Datatype = "html"
+		case model.TextPlain:
+			xu.Datatype = "plaintext"
+		}
+		doc.File.Units = append(doc.File.Units, xu)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write XLIFF: %v", err)
+	}
+	return encoder.Close()
+}
+
+// TranslationBundleFromXLIFF parses bundle written by XLIFF
+func TranslationBundleFromXLIFF(r io.Reader) (*TranslationBundle, error) {
+	doc := xliffDocument{}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse XLIFF: %v", err)
+	}
+
+	bundle := &TranslationBundle{
+		SourceLanguage: doc.File.SourceLanguage,
+		TargetLanguage: doc.File.TargetLanguage,
+	}
+	for _, xu := range doc.File.Units {
+		i := strings.LastIndex(xu.ID, "/")
+		if i < 0 || xu.Resname == "" {
+			return nil, fmt.Errorf("invalid XLIFF unit '%s': work item URI and field are required", xu.ID)
+		}
+		unit := TranslationUnit{
+			URI:    xu.Resname,
+			ID:     xu.ID[:i],
+			Field:  xu.ID[i+1:],
+			Source: xu.Source,
+			Target: xu.Target,
+		}
+		if unit.Field == TranslateDescription {
+			switch xu.Datatype {
+			case "html":
+				unit.Type = model.TextHTML
+			case "plaintext":
+				unit.Type = model.TextPlain
+			default:
+				unit.Type = defaultTranslationType
+			}
+		}
+		bundle.Units = append(bundle.Units, unit)
+	}
+	return bundle, nil
+}
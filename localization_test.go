@@ -0,0 +1,91 @@
+package polarion_wsdl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func TestTranslationBundleXLIFF(t *testing.T) {
+	uri := "subterra:data-service:objects:/default/P${WorkItem}P-1"
+	tests := []struct {
+		name string
+		unit TranslationUnit
+	}{
+		{"title", TranslationUnit{URI: uri, ID: "P-1", Field: TranslateTitle, Source: "Login", Target: "Anmeldung"}},
+		{"html description", TranslationUnit{URI: uri, ID: "P-1", Field: TranslateDescription, Type: model.TextHTML, Source: "<b>a</b>"}},
+		{"plain description", TranslationUnit{URI: uri, ID: "P-1", Field: TranslateDescription, Type: model.TextPlain, Source: "a", Target: "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &TranslationBundle{SourceLanguage: "en", TargetLanguage: "de", Units: []TranslationUnit{tt.unit}}
+			var b strings.Builder
+			if err := bundle.XLIFF(&b); err != nil {
+				t.Fatalf("XLIFF() error = %v", err)
+			}
+			got, err := TranslationBundleFromXLIFF(strings.NewReader(b.String()))
+			if err != nil {
+				t.Fatalf("TranslationBundleFromXLIFF() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, bundle) {
+				t.Errorf("round trip = %+v, want %+v", got, bundle)
+			}
+		})
+	}
+}
+
+func TestTranslationBundleFromXLIFFDefaultType(t *testing.T) {
+	doc := `<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">` +
+		`<file original="polarion" source-language="en" target-language="de" datatype="plaintext"><body>` +
+		`<trans-unit id="P-1/description" resname="uri"><source>a</source></trans-unit>` +
+		`</body></file></xliff>`
+	bundle, err := TranslationBundleFromXLIFF(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("TranslationBundleFromXLIFF() error = %v", err)
+	}
+	if got := bundle.Units[0].Type; got != defaultTranslationType {
+		t.Errorf("type = %q, want %q", got, defaultTranslationType)
+	}
+}
+
+func TestTranslationUnits(t *testing.T) {
+	uri := "subterra:data-service:objects:/default/P${WorkItem}P-1"
+	wi := &tracker_ws.WorkItem{Uri: model.NewURI(uri), Id: "P-1"}
+
+	tests := []struct {
+		name   string
+		source Localization
+		target Localization
+		want   []TranslationUnit
+	}{
+		{"nothing to translate", Localization{Description: model.NewHTMLText("")}, Localization{}, nil},
+		{
+			"not translated",
+			Localization{Title: "Brakes", Description: model.NewHTMLText("<p>Stop</p>")},
+			Localization{},
+			[]TranslationUnit{
+				{URI: uri, ID: "P-1", Field: TranslateTitle, Source: "Brakes"},
+				{URI: uri, ID: "P-1", Field: TranslateDescription, Type: model.TextHTML, Source: "<p>Stop</p>"},
+			},
+		},
+		{
+			"translated title only",
+			Localization{Title: "Brakes", Description: model.NewPlainText("Stop")},
+			Localization{Title: "Bremsen"},
+			[]TranslationUnit{
+				{URI: uri, ID: "P-1", Field: TranslateTitle, Source: "Brakes", Target: "Bremsen"},
+				{URI: uri, ID: "P-1", Field: TranslateDescription, Type: model.TextPlain, Source: "Stop"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := translationUnits(wi, tt.source, tt.target); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translationUnits() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// struct tag used by Decode and Encode, e.g.
+//
+//	type Requirement struct {
+//		ID     string    `polarion:"id"`
+//		Status string    `polarion:"status"`
+//		Owner  []string  `polarion:"assignee"`
+//		Asil   string    `polarion:"custom:asil,enum"`
+//		Due    time.Time `polarion:"custom:dueDate,date,optional"`
+//	}
+//
+// tag options:
+//   - optional: field is not reported as missing by Decode
+//   - enum: string value is encoded as enum option ID
+//   - date: time value is encoded as date without time
+//
+// Decode and Encode work with WorkItem instead of generated tracker_ws.WorkItem:
+// generated custom field values lose their XSI type, so they can be neither decoded
+// nor sent back, and generated work item can not tell fields which were not fetched
+// from empty ones. WorkItem embeds generated work item, &wi.WorkItem can be used
+// where tracker_ws.WorkItem is expected.
+const mappingTag = "polarion"
+
+const customTagPrefix = "custom:"
+
+// model.WorkItem field names which are not capitalized WorkItem XML names
+var modelFieldOverrides = map[string]string{
+	"id":                     "ID",
+	"uri":                    "URI",
+	"type":                   "Type",
+	"assignee":               "Assignees",
+	"linkedWorkItems":        "Links",
+	"linkedWorkItemsDerived": "DerivedLinks",
+	"plannedInURIs":          "PlannedIn",
+}
+
+// WorkItem field index by XML name, used to find fields which were not fetched
+var workItemFieldIndex = xmlFieldIndex(reflect.TypeOf(tracker_ws.WorkItem{}))
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	dateType     = reflect.TypeOf(Date{})
+	textType     = reflect.TypeOf(model.Text{})
+	userType     = reflect.TypeOf(model.User{})
+	projectType  = reflect.TypeOf(tracker_ws.Project{})
+	durationType = reflect.TypeOf(Duration{})
+)
+
+// MissingFieldsError is returned by Decode when work item has no value
+// for some of the mapped fields, all other fields are decoded.
+type MissingFieldsError struct {
+	Fields []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("work item has no value for fields: %s", strings.Join(e.Fields, ", "))
+}
+
+type mappedField struct {
+	index []int
+
+	// WorkItem XML name or custom field key
+	name     string
+	custom   bool
+	optional bool
+	enum     bool
+	date     bool
+}
+
+// Polarion field name as used in fields list of queries
+func (f mappedField) queryField() string {
+	if f.custom {
+		return customFieldsPrefix + f.name
+	}
+	return f.name
+}
+
+var mappingCache sync.Map // reflect.Type -> []mappedField
+
+func mappedFields(t reflect.Type) ([]mappedField, error) {
+	if cached, ok := mappingCache.Load(t); ok {
+		return cached.([]mappedField), nil
+	}
+
+	var fields []mappedField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(mappingTag)
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		field := mappedField{index: sf.Index, name: parts[0]}
+		if strings.HasPrefix(field.name, customTagPrefix) {
+			field.custom = true
+			field.name = strings.TrimPrefix(field.name, customTagPrefix)
+		} else if _, ok := workItemFieldNames[field.name]; !ok || field.name == "customFields" {
+			return nil, fmt.Errorf(
+				"unknown work item field '%s' in tag of %s.%s%s",
+				field.name, t.Name(), sf.Name, suggestion(field.name, workItemFieldNames),
+			)
+		}
+		if field.name == "" {
+			return nil, fmt.Errorf("empty field name in tag of %s.%s", t.Name(), sf.Name)
+		}
+
+		for _, option := range parts[1:] {
+			switch option {
+			case "optional":
+				field.optional = true
+			case "enum":
+				field.enum = true
+			case "date":
+				field.date = true
+			default:
+				return nil, fmt.Errorf("unknown tag option '%s' of %s.%s", option, t.Name(), sf.Name)
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	mappingCache.Store(t, fields)
+	return fields, nil
+}
+
+func structValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("expected non-nil pointer to struct, got %T", v)
+	}
+	return rv.Elem(), nil
+}
+
+func modelField(item *model.WorkItem, name string) reflect.Value {
+	fieldName, ok := modelFieldOverrides[name]
+	if !ok {
+		runes := []rune(name)
+		runes[0] = unicode.ToUpper(runes[0])
+		fieldName = string(runes)
+	}
+	return reflect.ValueOf(item).Elem().FieldByName(fieldName)
+}
+
+// MappedQueryFields returns fields list to query for values mapped by struct tags of v
+func MappedQueryFields(v any) ([]string, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %T", v)
+	}
+
+	fields, err := mappedFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.queryField())
+	}
+	return names, nil
+}
+
+// Decode copies work item fields to struct pointed by v according to its "polarion" tags.
+// Only custom fields referenced by tags are decoded. If some fields are not present
+// in work item (were not fetched or are not set) *MissingFieldsError is returned
+// after all other fields are decoded.
+func Decode(wi *WorkItem, v any) error {
+	if wi == nil {
+		return fmt.Errorf("cannot decode nil work item")
+	}
+	dst, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	fields, err := mappedFields(dst.Type())
+	if err != nil {
+		return err
+	}
+
+	item := model.WorkItemFromWS(&wi.WorkItem)
+
+	var missing []string
+	for _, field := range fields {
+		var src reflect.Value
+		if field.custom {
+			raw, ok := customValue(wi.CustomFields, field.name)
+			if !ok {
+				if !field.optional {
+					missing = append(missing, field.queryField())
+				}
+				continue
+			}
+			value, err := DecodeCustomValue(raw)
+			if err != nil {
+				return fmt.Errorf("failed to decode work item field '%s': %v", field.queryField(), err)
+			}
+			src = reflect.ValueOf(value)
+		} else {
+			if !wi.has(field.name) {
+				if !field.optional {
+					missing = append(missing, field.name)
+				}
+				continue
+			}
+			src = modelField(item, field.name)
+		}
+
+		if err := assignValue(dst.FieldByIndex(field.index), src); err != nil {
+			return fmt.Errorf("failed to decode work item field '%s': %v", field.queryField(), err)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingFieldsError{Fields: missing}
+	}
+	return nil
+}
+
+// Encode creates work item from struct pointed by v according to its "polarion" tags.
+// Zero values are not encoded.
+func Encode(v any) (*WorkItem, error) {
+	src, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := mappedFields(src.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	item := &model.WorkItem{}
+	var customFields []*Custom
+	for _, field := range fields {
+		value := src.FieldByIndex(field.index)
+		if value.IsZero() {
+			continue
+		}
+
+		if !field.custom {
+			if err := assignValue(modelField(item, field.name), value); err != nil {
+				return nil, fmt.Errorf("failed to encode work item field '%s': %v", field.name, err)
+			}
+			continue
+		}
+
+		encoded, err := EncodeCustomValue(customGoValue(value, field))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode work item custom field '%s': %v", field.name, err)
+		}
+		customFields = append(customFields, &Custom{Key: field.name, Value: encoded})
+	}
+
+	wi := &WorkItem{WorkItem: *item.ToWS()}
+	if len(customFields) > 0 {
+		wi.CustomFields = &ArrayOfCustom{Custom: customFields}
+	}
+	return wi, nil
+}
+
+// QueryAs queries work items fetching only fields mapped by struct tags of T
+// and decodes them. Missing field values are not treated as errors.
+func QueryAs[T any](ctx context.Context, p *Polarion, query, sortField string) ([]T, error) {
+	var zero T
+	fields, err := MappedQueryFields(&zero)
+	if err != nil {
+		return nil, err
+	}
+	if sortField == "" {
+		sortField = "id"
+	}
+
+	if err := p.ValidateWorkItemFields(ctx, queryProjectID(query), fields); err != nil {
+		return nil, err
+	}
+	items, err := p.queryWorkItems(ctx, query, sortField, fields)
+	if err != nil {
+		return nil, fmt.Errorf("error querying work items: %v", err)
+	}
+
+	result := make([]T, 0, len(items))
+	for _, wi := range items {
+		var value T
+		if err := Decode(wi, &value); err != nil {
+			if _, ok := err.(*MissingFieldsError); !ok {
+				return nil, fmt.Errorf("failed to decode work item %s: %v", wi.Id, err)
+			}
+		}
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+// converts native values of struct fields to values supported by EncodeCustomValue
+func customGoValue(value reflect.Value, field mappedField) any {
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+
+	switch {
+	case value.Type() == timeType && field.date:
+		return Date{value.Interface().(time.Time)}
+	case value.Kind() == reflect.String && field.enum:
+		return EnumID(value.String())
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		ids := make([]string, value.Len())
+		for i := range ids {
+			ids[i] = value.Index(i).String()
+		}
+		return ids
+	}
+
+	switch v := value.Interface().(type) {
+	case EnumID, Duration, tracker_ws.Duration, tracker_ws.Currency:
+		return v
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return int(value.Int())
+	case reflect.Int64:
+		return value.Int()
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+
+	if value.CanAddr() {
+		return value.Addr().Interface()
+	}
+	return value.Interface()
+}
+
+// assigns src to dst converting between mapped representations:
+// named types with the same kind, pointers and values, slices element by element,
+// Text and its content, User and its ID, Project and its ID, Date and time.Time,
+// Duration and its string form
+func assignValue(dst, src reflect.Value) error {
+	if !src.IsValid() {
+		return nil
+	}
+
+	if src.Kind() == reflect.Pointer || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			return nil
+		}
+		return assignValue(dst, src.Elem())
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assignValue(elem.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	switch {
+	case src.Type() == textType && dst.Kind() == reflect.String:
+		dst.SetString(src.FieldByName("Content").String())
+		return nil
+	case src.Kind() == reflect.String && dst.Type() == textType:
+		dst.Set(reflect.ValueOf(*model.NewPlainText(src.String())))
+		return nil
+	case src.Type() == userType && dst.Kind() == reflect.String:
+		dst.SetString(src.FieldByName("ID").String())
+		return nil
+	case src.Kind() == reflect.String && dst.Type() == userType:
+		dst.Set(reflect.ValueOf(model.User{ID: src.String()}))
+		return nil
+	case src.Type() == projectType && dst.Kind() == reflect.String:
+		dst.SetString(src.FieldByName("Id").String())
+		return nil
+	case src.Kind() == reflect.String && dst.Type() == durationType:
+		duration, err := ParseDuration(src.String())
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(duration))
+		return nil
+	case src.Type() == durationType && dst.Kind() == reflect.String:
+		dst.SetString(src.Interface().(Duration).String())
+		return nil
+	case src.Type() == dateType && dst.Type() == timeType:
+		dst.Set(src.Field(0))
+		return nil
+	case src.Type() == timeType && dst.Type() == dateType:
+		dst.Set(reflect.ValueOf(Date{src.Interface().(time.Time)}))
+		return nil
+	}
+
+	if src.Kind() == reflect.Slice && dst.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := assignValue(slice.Index(i), src.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+		return nil
+	}
+
+	if sameKindGroup(src.Kind(), dst.Kind()) && src.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("cannot assign %s to %s", src.Type(), dst.Type())
+}
+
+// prevents conversions such as int to string which reflect allows
+func sameKindGroup(a, b reflect.Kind) bool {
+	group := func(k reflect.Kind) int {
+		switch k {
+		case reflect.String:
+			return 1
+		case reflect.Bool:
+			return 2
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return 3
+		}
+		return int(k) + 100
+	}
+	return group(a) == group(b)
+}
@@ -0,0 +1,334 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+type mappedRequirement struct {
+	ID       string    `polarion:"id"`
+	Title    string    `polarion:"title,optional"`
+	Status   string    `polarion:"status"`
+	Owners   []string  `polarion:"assignee,optional"`
+	Due      time.Time `polarion:"dueDate,optional"`
+	Asil     string    `polarion:"custom:asil,enum"`
+	Verified bool      `polarion:"custom:verified"`
+	Review   time.Time `polarion:"custom:reviewDate,date,optional"`
+	Effort   Duration  `polarion:"custom:effort,optional"`
+	Ignored  string
+}
+
+type mappedUnresolvable struct {
+	Unresolvable bool `polarion:"unresolvable"`
+}
+
+// work item as returned by Polarion, unmarshalled to record present fields
+func unmarshalWorkItem(t *testing.T, content string) *WorkItem {
+	t.Helper()
+	wi := &WorkItem{}
+	if err := xml.Unmarshal([]byte(content), wi); err != nil {
+		t.Fatalf("unmarshal work item: %v", err)
+	}
+	return wi
+}
+
+const mappingCustomValueAttrs = `xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		xml         string
+		want        mappedRequirement
+		wantMissing []string
+		wantErr     bool
+	}{
+		{
+			name: "all fields",
+			xml: `<workItem uri="subterra:data-service:objects:/default/demo${WorkItem}DEMO-1">
+				<id>DEMO-1</id>
+				<title>Brakes</title>
+				<status><id>open</id></status>
+				<assignee><User><id>alice</id></User><User><id>bob</id></User></assignee>
+				<dueDate>2024-05-01</dueDate>
+				<customFields>
+					<Custom><key>asil</key><value ` + mappingCustomValueAttrs + ` xsi:type="tracker:EnumOptionId"><id>D</id></value></Custom>
+					<Custom><key>verified</key><value ` + mappingCustomValueAttrs + ` xsi:type="xsd:boolean">false</value></Custom>
+					<Custom><key>reviewDate</key><value ` + mappingCustomValueAttrs + ` xsi:type="xsd:date">2024-04-01</value></Custom>
+					<Custom><key>effort</key><value ` + mappingCustomValueAttrs + ` xsi:type="tracker:Duration">1d 2h</value></Custom>
+					</customFields>
+			</workItem>`,
+			want: mappedRequirement{
+				ID:       "DEMO-1",
+				Title:    "Brakes",
+				Status:   "open",
+				Owners:   []string{"alice", "bob"},
+				Due:      time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+				Asil:     "D",
+				Verified: false,
+				Review:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+				Effort:   Duration{Days: 1, Minutes: 120},
+			},
+		},
+		{
+			name: "missing required fields",
+			xml: `<workItem>
+				<id>DEMO-2</id>
+				<customFields>
+					<Custom><key>asil</key><value ` + mappingCustomValueAttrs + ` xsi:type="tracker:EnumOptionId"><id>B</id></value></Custom>
+				</customFields>
+			</workItem>`,
+			want:        mappedRequirement{ID: "DEMO-2", Asil: "B"},
+			wantMissing: []string{"status", "customFields.verified"},
+		},
+		{
+			name: "empty present value is not missing",
+			xml: `<workItem>
+				<id>DEMO-3</id>
+				<status/>
+				<customFields>
+					<Custom><key>asil</key><value ` + mappingCustomValueAttrs + ` xsi:type="tracker:EnumOptionId"><id>A</id></value></Custom>
+					<Custom><key>verified</key><value ` + mappingCustomValueAttrs + ` xsi:type="xsd:boolean">true</value></Custom>
+				</customFields>
+			</workItem>`,
+			want: mappedRequirement{ID: "DEMO-3", Asil: "A", Verified: true},
+		},
+		{
+			name: "unsupported type of unmapped custom field is ignored",
+			xml: `<workItem>
+				<id>DEMO-4</id>
+				<status><id>done</id></status>
+				<customFields>
+					<Custom><key>asil</key><value ` + mappingCustomValueAttrs + ` xsi:type="tracker:EnumOptionId"><id>C</id></value></Custom>
+					<Custom><key>verified</key><value ` + mappingCustomValueAttrs + ` xsi:type="xsd:boolean">true</value></Custom>
+					<Custom><key>other</key><value ` + mappingCustomValueAttrs + ` xsi:type="tracker:Unknown">?</value></Custom>
+				</customFields>
+			</workItem>`,
+			want: mappedRequirement{ID: "DEMO-4", Status: "done", Asil: "C", Verified: true},
+		},
+		{
+			name: "unsupported type of mapped custom field",
+			xml: `<workItem>
+				<customFields>
+					<Custom><key>asil</key><value ` + mappingCustomValueAttrs + ` xsi:type="tracker:Unknown">?</value></Custom>
+				</customFields>
+			</workItem>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got mappedRequirement
+			err := Decode(unmarshalWorkItem(t, tt.xml), &got)
+
+			var missing *MissingFieldsError
+			switch {
+			case tt.wantErr:
+				if err == nil || errors.As(err, &missing) {
+					t.Fatalf("error = %v, want decoding error", err)
+				}
+				return
+			case len(tt.wantMissing) > 0:
+				if !errors.As(err, &missing) || !slices.Equal(missing.Fields, tt.wantMissing) {
+					t.Fatalf("error = %v, want missing %v", err, tt.wantMissing)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePresentFalseBool(t *testing.T) {
+	tests := []struct {
+		name        string
+		xml         string
+		wantMissing bool
+	}{
+		{"false attribute is present", `<workItem unresolvable="false"/>`, false},
+		{"true attribute is present", `<workItem unresolvable="true"/>`, false},
+		{"absent attribute is missing", `<workItem/>`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got mappedUnresolvable
+			err := Decode(unmarshalWorkItem(t, tt.xml), &got)
+			var missing *MissingFieldsError
+			if errors.As(err, &missing) != tt.wantMissing {
+				t.Errorf("error = %v, want missing %v", err, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	req := mappedRequirement{
+		ID:       "DEMO-1",
+		Status:   "open",
+		Owners:   []string{"alice"},
+		Asil:     "D",
+		Verified: true,
+		Review:   time.Date(2024, 4, 1, 15, 0, 0, 0, time.UTC),
+		Effort:   Duration{Days: 1, Minutes: 30},
+	}
+
+	wi, err := Encode(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wi.Id != "DEMO-1" || model.EnumID(wi.Status) != "open" {
+		t.Errorf("standard fields not encoded: %+v", wi.WorkItem)
+	}
+	if wi.Assignee == nil || len(wi.Assignee.User) != 1 || wi.Assignee.User[0].Id != "alice" {
+		t.Errorf("assignees not encoded: %+v", wi.Assignee)
+	}
+
+	tests := []struct {
+		key       string
+		xsiType   string
+		innerXML  string
+		wantFound bool
+	}{
+		{"asil", "tracker:EnumOptionId", "<id>D</id>", true},
+		{"verified", "xsd:boolean", "true", true},
+		{"reviewDate", "xsd:date", "2024-04-01", true},
+		{"effort", "tracker:Duration", "1d 30m", true},
+		// zero values are not encoded
+		{"title", "", "", false},
+	}
+	for _, tt := range tests {
+		value, ok := customValue(wi.CustomFields, tt.key)
+		if ok != tt.wantFound {
+			t.Errorf("custom field %s found %v, want %v", tt.key, ok, tt.wantFound)
+			continue
+		}
+		if ok && (value.XSIType != tt.xsiType || value.InnerXML != tt.innerXML) {
+			t.Errorf("custom field %s = %+v, want %s %s", tt.key, value, tt.xsiType, tt.innerXML)
+		}
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	want := mappedRequirement{
+		ID:       "DEMO-1",
+		Status:   "open",
+		Asil:     "QM",
+		Verified: true,
+		Review:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+		Effort:   Duration{Minutes: 90},
+	}
+
+	wi, err := Encode(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := xml.Marshal(wi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got mappedRequirement
+	if err := Decode(unmarshalWorkItem(t, string(b)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestMappedFieldsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"unknown field", &struct {
+			Status string `polarion:"stauts"`
+		}{}},
+		{"custom fields as standard field", &struct {
+			Custom string `polarion:"customFields"`
+		}{}},
+		{"empty custom key", &struct {
+			Custom string `polarion:"custom:"`
+		}{}},
+		{"unknown option", &struct {
+			Status string `polarion:"status,required"`
+		}{}},
+		{"not a struct pointer", mappedRequirement{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Decode(&WorkItem{}, tt.value); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestMappedQueryFields(t *testing.T) {
+	fields, err := MappedQueryFields(mappedRequirement{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"id", "title", "status", "assignee", "dueDate",
+		"customFields.asil", "customFields.verified", "customFields.reviewDate", "customFields.effort",
+	}
+	if !slices.Equal(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+}
+
+func TestAssignValue(t *testing.T) {
+	type named string
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		src     any
+		dst     any
+		want    any
+		wantErr bool
+	}{
+		{"same type", "a", new(string), "a", false},
+		{"named string", "a", new(named), named("a"), false},
+		{"pointer destination", "a", new(*string), func() *string { s := "a"; return &s }(), false},
+		{"text to string", *model.NewPlainText("t"), new(string), "t", false},
+		{"string to text", "t", new(model.Text), *model.NewPlainText("t"), false},
+		{"user to string", model.User{ID: "u"}, new(string), "u", false},
+		{"project to string", tracker_ws.Project{Id: "p"}, new(string), "p", false},
+		{"date to time", Date{day}, new(time.Time), day, false},
+		{"time to date", day, new(Date), Date{day}, false},
+		{"string to duration", "1h 30m", new(Duration), Duration{Minutes: 90}, false},
+		{"duration to string", Duration{Days: 1}, new(string), "1d", false},
+		{"users to IDs", []model.User{{ID: "a"}, {ID: "b"}}, new([]string), []string{"a", "b"}, false},
+		{"int to float", 2, new(float64), 2.0, false},
+		{"int to string", 2, new(string), nil, true},
+		{"invalid duration", "x", new(Duration), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := reflect.ValueOf(tt.dst).Elem()
+			err := assignValue(dst, reflect.ValueOf(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(dst.Interface(), tt.want) {
+				t.Errorf("assigned %#v, want %#v", dst.Interface(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// Comment is work item comment, Replies are filled when comments are arranged into threads
+type Comment struct {
+	URI       string
+	ID        string
+	Title     string
+	Author    *User
+	Created   time.Time
+	Text      *Text
+	Resolved  bool
+	Tags      []string
+	VisibleTo []string
+
+	// URI of comment this one replies to, empty for top-level comments
+	ParentURI string
+	Replies   []*Comment
+}
+
+func CommentFromWS(c *tracker_ws.Comment) *Comment {
+	if c == nil {
+		return nil
+	}
+
+	comment := &Comment{
+		URI:       URI(c.Uri),
+		ID:        c.Id,
+		Title:     c.Title,
+		Author:    UserFromWS(c.Author),
+		Created:   FromXSDDateTime(c.Created),
+		Text:      TextFromWS(c.Text),
+		Resolved:  c.Resolved,
+		ParentURI: URI(c.ParentCommentURI),
+	}
+	if c.Tags != nil {
+		for _, tag := range c.Tags.EnumOptionId {
+			if id := EnumID(tag); id != "" {
+				comment.Tags = append(comment.Tags, id)
+			}
+		}
+	}
+	if c.VisibleTo != nil {
+		comment.VisibleTo = c.VisibleTo.Astring
+	}
+	return comment
+}
+
+// Markdown returns text of comment converted to Markdown
+func (c *Comment) Markdown() Conversion {
+	if c.Text == nil {
+		return Conversion{}
+	}
+	return c.Text.Markdown()
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/xml"
+	"slices"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func TestCommentMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     *Text
+		want     string
+		warnings []string
+	}{
+		{"without text", nil, "", nil},
+		{"html", NewHTMLText("<p>Looks <b>good</b></p><ul><li>a</li><li>b</li></ul>"), "Looks **good**\n\n- a\n- b", nil},
+		{"plain text", NewPlainText("- not a list\n2 * 3"), "\\- not a list\n2 \\* 3", nil},
+		{
+			"lossy text",
+			&Text{Type: TextHTML, Content: "<p>a</p>", ContentLossy: true},
+			"a",
+			[]string{"text content was already lossy when received from Polarion"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (&Comment{Text: tt.text}).Markdown()
+			if got.Content != tt.want {
+				t.Errorf("Markdown() = %q, want %q", got.Content, tt.want)
+			}
+			if !slices.Equal(got.Warnings, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", got.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestCommentFromWS(t *testing.T) {
+	content := `<Comment uri="subterra:data-service:objects:/default/demo${Comment}DEMO-1/2">
+		<id>2</id>
+		<created>2024-01-01T10:00:00Z</created>
+		<parentCommentURI>subterra:data-service:objects:/default/demo${Comment}DEMO-1/1</parentCommentURI>
+		<resolved>true</resolved>
+		<tags><EnumOptionId><id>question</id></EnumOptionId></tags>
+		<text><type>text/html</type><content>&lt;p&gt;1. done&lt;/p&gt;</content></text>
+		<visibleTo><string>alice</string></visibleTo>
+	</Comment>`
+	var c tracker_ws.Comment
+	if err := xml.Unmarshal([]byte(content), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	comment := CommentFromWS(&c)
+	if comment.ID != "2" || !comment.Resolved || comment.Created.IsZero() {
+		t.Errorf("comment = %+v", comment)
+	}
+	if comment.ParentURI != "subterra:data-service:objects:/default/demo${Comment}DEMO-1/1" {
+		t.Errorf("parent URI = %q", comment.ParentURI)
+	}
+	if !slices.Equal(comment.Tags, []string{"question"}) || !slices.Equal(comment.VisibleTo, []string{"alice"}) {
+		t.Errorf("tags = %q, visible to %q", comment.Tags, comment.VisibleTo)
+	}
+	if got := comment.Markdown().Content; got != `1\. done` {
+		t.Errorf("Markdown() = %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import "context"
+
+// EnumLabeler resolves enum option IDs to display names
+type EnumLabeler interface {
+	// EnumLabel returns name of option or the ID itself if option is unknown
+	EnumLabel(ctx context.Context, enumID, optionID string) string
+}
+
+// EnumLabels returns display names of enum fields of work item keyed by field name,
+// fields without value are omitted
+func (wi *WorkItem) EnumLabels(ctx context.Context, labeler EnumLabeler) map[string]string {
+	labels := map[string]string{}
+	for enumID, id := range map[string]string{
+		"type":           wi.Type,
+		"status":         wi.Status,
+		"previousStatus": wi.PreviousStatus,
+		"resolution":     wi.Resolution,
+		"severity":       wi.Severity,
+		"priority":       wi.Priority,
+	} {
+		if id == "" {
+			continue
+		}
+		if enumID == "previousStatus" {
+			labels[enumID] = labeler.EnumLabel(ctx, "status", id)
+			continue
+		}
+		labels[enumID] = labeler.EnumLabel(ctx, enumID, id)
+	}
+	return labels
+}
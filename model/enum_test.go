@@ -0,0 +1,54 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type testLabeler map[string]string
+
+func (l testLabeler) EnumLabel(ctx context.Context, enumID, optionID string) string {
+	if label, ok := l[enumID+"/"+optionID]; ok {
+		return label
+	}
+	return optionID
+}
+
+func TestEnumLabels(t *testing.T) {
+	labeler := testLabeler{
+		"type/requirement": "Requirement",
+		"status/open":      "Open",
+		"status/draft":     "Draft",
+		"severity/major":   "Major",
+	}
+	tests := []struct {
+		name string
+		item *WorkItem
+		want map[string]string
+	}{
+		{"empty", &WorkItem{}, map[string]string{}},
+		{
+			"labeled",
+			&WorkItem{Type: "requirement", Status: "open", Severity: "major"},
+			map[string]string{"type": "Requirement", "status": "Open", "severity": "Major"},
+		},
+		{
+			"previous status uses status enum",
+			&WorkItem{Status: "open", PreviousStatus: "draft"},
+			map[string]string{"status": "Open", "previousStatus": "Draft"},
+		},
+		{
+			"unknown option falls back to ID",
+			&WorkItem{Priority: "90.0"},
+			map[string]string{"priority": "90.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.EnumLabels(context.Background(), labeler); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EnumLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package model
+
+import "github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+
+// Link is a link from work item to another work item
+type Link struct {
+	// URI of linked work item
+	URI  string
+	Role string
+
+	// revision the link is pinned to, empty for HEAD
+	Revision string
+	Suspect  bool
+}
+
+func LinkFromWS(l *tracker_ws.LinkedWorkItem) Link {
+	return Link{
+		URI:      URI(l.WorkItemURI),
+		Role:     EnumID(l.Role),
+		Revision: l.Revision,
+		Suspect:  l.Suspect,
+	}
+}
+
+func (l Link) ToWS() *tracker_ws.LinkedWorkItem {
+	return &tracker_ws.LinkedWorkItem{
+		WorkItemURI: NewURI(l.URI),
+		Role:        NewEnumID(l.Role),
+		Revision:    l.Revision,
+		Suspect:     l.Suspect,
+	}
+}
+
+func linksFromWS(links *tracker_ws.ArrayOfLinkedWorkItem) []Link {
+	if links == nil {
+		return nil
+	}
+	result := make([]Link, 0, len(links.LinkedWorkItem))
+	for _, l := range links.LinkedWorkItem {
+		if l != nil {
+			result = append(result, LinkFromWS(l))
+		}
+	}
+	return result
+}
+
+func linksToWS(links []Link) *tracker_ws.ArrayOfLinkedWorkItem {
+	if links == nil {
+		return nil
+	}
+	result := &tracker_ws.ArrayOfLinkedWorkItem{
+		LinkedWorkItem: make([]*tracker_ws.LinkedWorkItem, 0, len(links)),
+	}
+	for _, l := range links {
+		result.LinkedWorkItem = append(result.LinkedWorkItem, l.ToWS())
+	}
+	return result
+}
+
+// Hyperlink is a link from work item to external URL
+type Hyperlink struct {
+	URI  string
+	Role string
+}
+
+func hyperlinksFromWS(links *tracker_ws.ArrayOfHyperlink) []Hyperlink {
+	if links == nil {
+		return nil
+	}
+	result := make([]Hyperlink, 0, len(links.Hyperlink))
+	for _, l := range links.Hyperlink {
+		if l != nil {
+			result = append(result, Hyperlink{URI: l.Uri, Role: EnumID(l.Role)})
+		}
+	}
+	return result
+}
+
+func hyperlinksToWS(links []Hyperlink) *tracker_ws.ArrayOfHyperlink {
+	if links == nil {
+		return nil
+	}
+	result := &tracker_ws.ArrayOfHyperlink{
+		Hyperlink: make([]*tracker_ws.Hyperlink, 0, len(links)),
+	}
+	for _, l := range links {
+		result.Hyperlink = append(result.Hyperlink, &tracker_ws.Hyperlink{
+			Uri:  l.URI,
+			Role: NewEnumID(l.Role),
+		})
+	}
+	return result
+}
@@ -0,0 +1,612 @@
+package model
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markdown link scheme for Polarion work item references,
+// e.g. [PRJ-12](polarion:PRJ/PRJ-12?option=short)
+const polarionLinkScheme = "polarion:"
+
+// image source scheme for work item attachments in Polarion rich text and Markdown,
+// e.g. <img src="attachment:1-diagram.png"/> and ![](attachment:1-diagram.png)
+const attachmentScheme = "attachment:"
+
+// download URL of work item attachment, e.g. /polarion/wi-attachment/PRJ/PRJ-1/1-diagram.png
+var attachmentURLRe = regexp.MustCompile(`^(?:https?://[^/]+)?/polarion/wi-attachment/[^/]+/[^/]+/([^/?#]+)$`)
+
+// class of Polarion rich text macros (work item references, document links...)
+const rteLinkClass = "polarion-rte-link"
+
+// Conversion is result of rich text conversion,
+// Warnings describe information which was lost (similar to Text.ContentLossy)
+type Conversion struct {
+	Content  string
+	Warnings []string
+}
+
+func (c Conversion) Lossy() bool {
+	return len(c.Warnings) > 0
+}
+
+// Markdown converts text content to Markdown
+func (t *Text) Markdown() Conversion {
+	if t == nil {
+		return Conversion{}
+	}
+
+	var conversion Conversion
+	if t.IsHTML() {
+		conversion = HTMLToMarkdown(t.Content)
+	} else {
+		conversion = Conversion{Content: escapeLineStarts(escapeMarkdown(t.Content))}
+	}
+
+	if t.ContentLossy {
+		conversion.Warnings = append(
+			[]string{"text content was already lossy when received from Polarion"},
+			conversion.Warnings...,
+		)
+	}
+	return conversion
+}
+
+// TextFromMarkdown converts Markdown to HTML text,
+// warnings describe Markdown constructs which could not be represented
+func TextFromMarkdown(md string) (*Text, []string) {
+	conversion := MarkdownToHTML(md)
+	return NewHTMLText(conversion.Content), conversion.Warnings
+}
+
+type htmlNode struct {
+	// empty for text nodes
+	tag      string
+	attrs    map[string]string
+	text     string
+	children []*htmlNode
+}
+
+func (n *htmlNode) attr(name string) string {
+	return n.attrs[name]
+}
+
+func (n *htmlNode) textContent() string {
+	if n.tag == "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(child.textContent())
+	}
+	return b.String()
+}
+
+func (n *htmlNode) isRTELink() bool {
+	return n.tag == "span" && strings.Contains(" "+n.attr("class")+" ", " "+rteLinkClass+" ")
+}
+
+// parses HTML fragment using non-strict XML decoder, which handles
+// void elements, HTML entities and unclosed tags used in Polarion rich text
+func parseHTML(content string) (*htmlNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<div>" + content + "</div>"))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	root := &htmlNode{tag: "root"}
+	stack := []*htmlNode{root}
+	// end tags of elements closed implicitly, which decoder still reports
+	pendingEnds := map[string]int{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &htmlNode{tag: strings.ToLower(t.Name.Local), attrs: map[string]string{}}
+			for _, a := range t.Attr {
+				name := strings.ToLower(a.Name.Local)
+				if a.Name.Space != "" && a.Name.Space != "xmlns" {
+					name = strings.ToLower(a.Name.Space) + ":" + name
+				}
+				node.attrs[name] = a.Value
+			}
+			if i := impliedEnd(stack, node.tag); i > 0 {
+				for _, closed := range stack[i:] {
+					pendingEnds[closed.tag]++
+				}
+				stack = stack[:i]
+			}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			tag := strings.ToLower(t.Name.Local)
+			if top := stack[len(stack)-1]; top.tag != tag && pendingEnds[tag] > 0 {
+				pendingEnds[tag]--
+				continue
+			}
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, &htmlNode{text: string(t)})
+		}
+	}
+
+	if len(root.children) == 1 {
+		return root.children[0], nil
+	}
+	return root, nil
+}
+
+// returns stack index of unclosed element which is closed by start of tag as in HTML,
+// e.g. "<p>a<p>b" are two paragraphs, or 0 if tag does not close any element
+func impliedEnd(stack []*htmlNode, tag string) int {
+	if !blockTags[tag] {
+		return 0
+	}
+	for i := len(stack) - 1; i > 0; i-- {
+		switch open := stack[i].tag; {
+		case open == "p":
+			return i
+		case open == "li" && tag == "li":
+			return i
+		case blockTags[open]:
+			return 0
+		}
+	}
+	return 0
+}
+
+var blockTags = map[string]bool{
+	"p": true, "div": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "table": true, "thead": true, "tbody": true, "tfoot": true,
+	"tr": true, "td": true, "th": true, "pre": true, "blockquote": true, "hr": true,
+}
+
+// styling elements which have no Markdown counterpart, their text is kept
+var unsupportedInlineTags = map[string]string{
+	"u":    "underline",
+	"sup":  "superscript",
+	"sub":  "subscript",
+	"font": "font formatting",
+}
+
+type markdownWriter struct {
+	warnings []string
+	inTable  bool
+}
+
+func (w *markdownWriter) warn(format string, args ...any) {
+	warning := fmt.Sprintf(format, args...)
+	for _, existing := range w.warnings {
+		if existing == warning {
+			return
+		}
+	}
+	w.warnings = append(w.warnings, warning)
+}
+
+// HTMLToMarkdown converts Polarion rich text to GitHub flavoured Markdown.
+// Work item references become "polarion:" links, attachment images and their download
+// URLs become "attachment:" images and formatting without Markdown syntax is reported in warnings.
+func HTMLToMarkdown(content string) Conversion {
+	root, err := parseHTML(content)
+	if err != nil {
+		plain := (&Text{Type: TextHTML, Content: content}).Plain()
+		return Conversion{
+			Content:  escapeLineStarts(escapeMarkdown(plain)),
+			Warnings: []string{fmt.Sprintf("failed to parse HTML, converted as plain text: %v", err)},
+		}
+	}
+
+	w := &markdownWriter{}
+	blocks := w.blocks(root.children)
+	return Conversion{
+		Content:  strings.Join(blocks, "\n\n"),
+		Warnings: w.warnings,
+	}
+}
+
+func (w *markdownWriter) blocks(nodes []*htmlNode) []string {
+	var blocks []string
+	var inline []*htmlNode
+
+	flush := func() {
+		if s := escapeLineStarts(cleanInline(w.inline(inline))); s != "" {
+			blocks = append(blocks, s)
+		}
+		inline = nil
+	}
+
+	for _, node := range nodes {
+		if blockTags[node.tag] {
+			flush()
+			if s := w.block(node); s != "" {
+				blocks = append(blocks, s)
+			}
+			continue
+		}
+		inline = append(inline, node)
+	}
+	flush()
+
+	return blocks
+}
+
+func (w *markdownWriter) block(n *htmlNode) string {
+	w.checkStyle(n)
+
+	switch n.tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.tag[1:])
+		return strings.Repeat("#", level) + " " + cleanInline(w.inline(n.children))
+	case "ul", "ol":
+		return w.list(n)
+	case "pre":
+		return "```\n" + strings.Trim(n.textContent(), "\n") + "\n```"
+	case "blockquote":
+		lines := strings.Split(strings.Join(w.blocks(n.children), "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case "hr":
+		return "---"
+	case "table":
+		return w.table(n)
+	}
+
+	return strings.Join(w.blocks(n.children), "\n\n")
+}
+
+func (w *markdownWriter) list(n *htmlNode) string {
+	var items []string
+	number := 1
+	if start, err := strconv.Atoi(n.attr("start")); err == nil {
+		number = start
+	}
+
+	for _, child := range n.children {
+		if child.tag == "" && strings.TrimSpace(child.text) == "" {
+			continue
+		}
+
+		marker := "- "
+		if n.tag == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		var content string
+		if child.tag == "li" {
+			w.checkStyle(child)
+			content = strings.Join(w.blocks(child.children), "\n")
+		} else {
+			content = strings.Join(w.blocks([]*htmlNode{child}), "\n")
+		}
+
+		lines := strings.Split(content, "\n")
+		indent := strings.Repeat(" ", len(marker))
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = indent + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+func (w *markdownWriter) table(n *htmlNode) string {
+	var rows [][]string
+	var collectRows func(node *htmlNode)
+	collectRows = func(node *htmlNode) {
+		for _, child := range node.children {
+			switch child.tag {
+			case "thead", "tbody", "tfoot":
+				collectRows(child)
+			case "tr":
+				var cells []string
+				for _, cell := range child.children {
+					if cell.tag != "td" && cell.tag != "th" {
+						continue
+					}
+					if len(rows) == 0 && cell.tag == "td" {
+						w.warn("first table row is converted to header")
+					}
+					if cell.attr("colspan") != "" || cell.attr("rowspan") != "" {
+						w.warn("merged table cells are split")
+					}
+					cells = append(cells, w.tableCell(cell))
+				}
+				rows = append(rows, cells)
+			case "caption":
+				w.warn("table caption is dropped")
+			}
+		}
+	}
+	collectRows(n)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (w *markdownWriter) tableCell(cell *htmlNode) string {
+	w.inTable = true
+	defer func() { w.inTable = false }()
+
+	blocks := w.blocks(cell.children)
+	for _, block := range blocks {
+		if strings.Contains(strings.ReplaceAll(block, "\\\n", ""), "\n") {
+			w.warn("block content of table cells is flattened")
+		}
+	}
+
+	content := strings.Join(blocks, "<br>")
+	content = strings.ReplaceAll(content, "\\\n", "<br>")
+	content = strings.ReplaceAll(content, "\n", " ")
+	return strings.ReplaceAll(content, "|", "\\|")
+}
+
+func (w *markdownWriter) inline(nodes []*htmlNode) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(w.inlineNode(n))
+	}
+	return b.String()
+}
+
+func (w *markdownWriter) inlineNode(n *htmlNode) string {
+	if n.tag == "" {
+		return escapeMarkdown(collapseSpaces(n.text))
+	}
+
+	switch n.tag {
+	case "br":
+		return "\\\n"
+	case "b", "strong":
+		return wrapInline("**", w.inline(n.children))
+	case "i", "em":
+		return wrapInline("*", w.inline(n.children))
+	case "s", "strike", "del":
+		return wrapInline("~~", w.inline(n.children))
+	case "code", "tt":
+		return wrapInline("`", strings.ReplaceAll(n.textContent(), "`", "'"))
+	case "a":
+		text := w.inline(n.children)
+		href := n.attr("href")
+		if href == "" {
+			return text
+		}
+		if strings.TrimSpace(text) == "" {
+			text = escapeMarkdown(href)
+		}
+		return "[" + text + "](" + markdownURL(href) + ")"
+	case "img":
+		return w.image(n)
+	case "span":
+		if n.isRTELink() {
+			return w.rteLink(n)
+		}
+		return w.styledSpan(n)
+	}
+
+	if blockTags[n.tag] {
+		// block element nested in inline one
+		return " " + strings.Join(w.blocks([]*htmlNode{n}), "\\\n") + " "
+	}
+
+	if name, ok := unsupportedInlineTags[n.tag]; ok {
+		w.warn("%s is not supported in Markdown", name)
+	} else {
+		w.warn("unsupported element <%s> converted to text", n.tag)
+	}
+	return w.inline(n.children)
+}
+
+func (w *markdownWriter) image(n *htmlNode) string {
+	src := n.attr("src")
+	if src == "" {
+		return ""
+	}
+	for _, attr := range []string{"width", "height", "style"} {
+		if n.attr(attr) != "" {
+			w.warn("image size and style are dropped")
+		}
+	}
+	return "![" + escapeMarkdown(n.attr("alt")) + "](" + markdownImageSource(src) + ")"
+}
+
+// maps Polarion image source to Markdown image destination,
+// work item attachments are referred to by "attachment:" and escaped attachment ID
+func markdownImageSource(src string) string {
+	id, ok := strings.CutPrefix(src, attachmentScheme)
+	if !ok {
+		m := attachmentURLRe.FindStringSubmatch(src)
+		if m == nil {
+			return markdownURL(src)
+		}
+		id = m[1]
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+	}
+	return attachmentScheme + url.PathEscape(id)
+}
+
+// <span class="polarion-rte-link" data-type="workItem" data-item-id="PRJ-1" data-scope="PRJ" data-option-id="long"/>
+// to [PRJ-1](polarion:PRJ/PRJ-1)
+func (w *markdownWriter) rteLink(n *htmlNode) string {
+	itemID := n.attr("data-item-id")
+	if itemID == "" {
+		// other macros (document links, etc.) are kept as inline HTML
+		return renderHTML(n)
+	}
+
+	link := polarionLinkScheme + itemID
+	if scope := n.attr("data-scope"); scope != "" {
+		link = polarionLinkScheme + scope + "/" + itemID
+	}
+
+	query := url.Values{}
+	if option := n.attr("data-option-id"); option != "" && option != "long" {
+		query.Set("option", option)
+	}
+	if revision := n.attr("data-revision"); revision != "" {
+		query.Set("revision", revision)
+	}
+	if linkType := n.attr("data-type"); linkType != "" && linkType != "workItem" {
+		query.Set("type", linkType)
+	}
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+
+	return "[" + escapeMarkdown(itemID) + "](" + link + ")"
+}
+
+// Polarion editor marks bold, italic and strikethrough text with span styles
+func (w *markdownWriter) styledSpan(n *htmlNode) string {
+	content := w.inline(n.children)
+	for _, declaration := range strings.Split(n.attr("style"), ";") {
+		property, value, _ := strings.Cut(declaration, ":")
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		switch {
+		case property == "":
+		case property == "font-weight" && (value == "bold" || value == "700"):
+			content = wrapInline("**", content)
+		case property == "font-style" && value == "italic":
+			content = wrapInline("*", content)
+		case property == "text-decoration" && value == "line-through":
+			content = wrapInline("~~", content)
+		case property == "text-decoration" && value == "underline":
+			w.warn("underline is not supported in Markdown")
+		default:
+			if !w.inTable {
+				w.warn("inline styles (colors, fonts, alignment) are dropped")
+			}
+		}
+	}
+	return content
+}
+
+func (w *markdownWriter) checkStyle(n *htmlNode) {
+	if n.attr("style") != "" && !w.inTable {
+		w.warn("inline styles (colors, fonts, alignment) are dropped")
+	}
+}
+
+// serializes node back to HTML
+func renderHTML(n *htmlNode) string {
+	if n.tag == "" {
+		return escapeHTML(n.text)
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + n.tag)
+	for _, name := range sortedKeys(n.attrs) {
+		b.WriteString(" " + name + "=\"" + escapeHTML(n.attrs[name]) + "\"")
+	}
+	b.WriteString(">")
+	for _, child := range n.children {
+		b.WriteString(renderHTML(child))
+	}
+	b.WriteString("</" + n.tag + ">")
+	return b.String()
+}
+
+var (
+	spacesRe            = regexp.MustCompile(`[ \t\r\n]+`)
+	markdownSpecialsRe  = regexp.MustCompile("([\\\\`*_\\[\\]<])")
+	lineStartMarkerRe   = regexp.MustCompile(`(?m)^([ \t]*)([#>+-])`)
+	lineStartNumberRe   = regexp.MustCompile(`(?m)^([ \t]*\d+)([.)])`)
+	spacesAroundBreakRe = regexp.MustCompile(` *\\\n *`)
+	repeatedSpacesRe    = regexp.MustCompile(` {2,}`)
+)
+
+func collapseSpaces(s string) string {
+	return spacesRe.ReplaceAllString(s, " ")
+}
+
+func escapeMarkdown(s string) string {
+	return markdownSpecialsRe.ReplaceAllString(s, `\$1`)
+}
+
+// escapes text at line starts which would be read as heading, quote or list item
+func escapeLineStarts(s string) string {
+	s = lineStartMarkerRe.ReplaceAllString(s, `$1\$2`)
+	return lineStartNumberRe.ReplaceAllString(s, `$1\$2`)
+}
+
+func cleanInline(s string) string {
+	s = spacesAroundBreakRe.ReplaceAllString(s, "\\\n")
+	s = repeatedSpacesRe.ReplaceAllString(s, " ")
+	s = strings.TrimSpace(s)
+	return strings.TrimSuffix(s, "\\")
+}
+
+// keeps surrounding spaces outside of emphasis markers, "** a **" is not emphasis
+func wrapInline(marker, content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+	leading := content[:len(content)-len(strings.TrimLeft(content, " "))]
+	trailing := content[len(strings.TrimRight(content, " ")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+// URLs with spaces or parentheses are enclosed in angle brackets
+func markdownURL(u string) string {
+	if strings.ContainsAny(u, " ()") {
+		return "<" + u + ">"
+	}
+	return u
+}
@@ -0,0 +1,570 @@
+package model
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingRe        = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	fenceRe          = regexp.MustCompile("^\\s*(```|~~~)")
+	ruleRe           = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))*\s*$`)
+	listItemRe       = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	taskRe           = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	tableSeparatorRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	quoteRe          = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	htmlBlockRe      = regexp.MustCompile(`^\s*<(div|p|table|ul|ol|pre|h[1-6]|blockquote|span)\b`)
+	inlineTagRe      = regexp.MustCompile(`^</?[A-Za-z][A-Za-z0-9-]*(\s+[^<>]*)?/?>`)
+	autolinkRe       = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]*:[^<>\s]+)>`)
+	entityRe         = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+	markdownEscapeRe = regexp.MustCompile(`\\(.)`)
+)
+
+type htmlWriter struct {
+	warnings []string
+}
+
+func (w *htmlWriter) warn(warning string) {
+	for _, existing := range w.warnings {
+		if existing == warning {
+			return
+		}
+	}
+	w.warnings = append(w.warnings, warning)
+}
+
+// MarkdownToHTML converts GitHub flavoured Markdown to Polarion rich text.
+// "polarion:" links become work item references and "attachment:" images
+// refer to work item attachments (see HTMLToMarkdown for the syntax).
+func MarkdownToHTML(md string) Conversion {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	w := &htmlWriter{}
+	html := w.blocks(strings.Split(md, "\n"))
+	return Conversion{Content: html, Warnings: w.warnings}
+}
+
+func (w *htmlWriter) blocks(lines []string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fenceRe.MatchString(line):
+			fence := fenceRe.FindStringSubmatch(line)[1]
+			var code []string
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // closing fence
+			b.WriteString("<pre>" + escapeHTML(strings.Join(code, "\n")) + "</pre>")
+
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + w.inline(m[2]) + "</h" + level + ">")
+			i++
+
+		case ruleRe.MatchString(line) && len(strings.ReplaceAll(strings.TrimSpace(line), " ", "")) >= 3:
+			b.WriteString("<hr/>")
+			i++
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableSeparatorRe.MatchString(lines[i+1]):
+			end := i + 2
+			for end < len(lines) && strings.Contains(lines[end], "|") && strings.TrimSpace(lines[end]) != "" {
+				end++
+			}
+			b.WriteString(w.table(lines[i], lines[i+1], lines[i+2:end]))
+			i = end
+
+		case quoteRe.MatchString(line):
+			var quoted []string
+			for i < len(lines) && quoteRe.MatchString(lines[i]) {
+				quoted = append(quoted, quoteRe.FindStringSubmatch(lines[i])[1])
+				i++
+			}
+			b.WriteString("<blockquote>" + w.blocks(quoted) + "</blockquote>")
+
+		case listItemRe.MatchString(line):
+			var html string
+			html, i = w.list(lines, i)
+			b.WriteString(html)
+
+		case htmlBlockRe.MatchString(line):
+			// raw HTML is passed through until blank line
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+				b.WriteString(lines[i])
+				if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					b.WriteString("\n")
+				}
+				i++
+			}
+
+		default:
+			var paragraph []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && (len(paragraph) == 0 || !startsBlock(lines, i)) {
+				paragraph = append(paragraph, lines[i])
+				i++
+			}
+			b.WriteString("<p>" + w.inline(strings.Join(paragraph, "\n")) + "</p>")
+		}
+	}
+
+	return b.String()
+}
+
+// line interrupts paragraph
+func startsBlock(lines []string, i int) bool {
+	line := lines[i]
+	return fenceRe.MatchString(line) ||
+		headingRe.MatchString(line) ||
+		quoteRe.MatchString(line) ||
+		listItemRe.MatchString(line) ||
+		(ruleRe.MatchString(line) && len(strings.TrimSpace(line)) >= 3) ||
+		(i+1 < len(lines) && strings.Contains(line, "|") && tableSeparatorRe.MatchString(lines[i+1]))
+}
+
+func listMarker(match []string) (indent int, ordered bool, start int) {
+	indent = len(strings.ReplaceAll(match[1], "\t", "    "))
+	if n, err := strconv.Atoi(strings.TrimRight(match[2], ".)")); err == nil {
+		return indent, true, n
+	}
+	return indent, false, 0
+}
+
+// parses list starting at line i, returns HTML and index of first line after the list
+func (w *htmlWriter) list(lines []string, i int) (string, int) {
+	first := listItemRe.FindStringSubmatch(lines[i])
+	indent, ordered, start := listMarker(first)
+
+	var b strings.Builder
+	if ordered {
+		if start != 1 {
+			b.WriteString(`<ol start="` + strconv.Itoa(start) + `">`)
+		} else {
+			b.WriteString("<ol>")
+		}
+	} else {
+		b.WriteString("<ul>")
+	}
+
+	for i < len(lines) {
+		m := listItemRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		itemIndent, itemOrdered, _ := listMarker(m)
+		if itemIndent != indent || itemOrdered != ordered {
+			break
+		}
+
+		contentIndent := len(m[0]) - len(m[3])
+		item := []string{m[3]}
+		i++
+
+		// continuation lines: indented content, lazy paragraph lines and blank lines followed by indented content
+		for i < len(lines) {
+			line := lines[i]
+			lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+			if strings.TrimSpace(line) == "" {
+				if i+1 < len(lines) && len(lines[i+1])-len(strings.TrimLeft(lines[i+1], " \t")) > indent &&
+					strings.TrimSpace(lines[i+1]) != "" {
+					item = append(item, "")
+					i++
+					continue
+				}
+				break
+			}
+			if lineIndent > indent {
+				item = append(item, line[min(lineIndent, contentIndent):])
+				i++
+				continue
+			}
+			if listItemRe.MatchString(line) || startsBlock(lines, i) || item[len(item)-1] == "" {
+				break
+			}
+			item = append(item, line)
+			i++
+		}
+
+		if task := taskRe.FindStringSubmatch(item[0]); task != nil {
+			w.warn("task list checkboxes are converted to text")
+			mark := "☐ "
+			if task[1] != " " {
+				mark = "☑ "
+			}
+			item[0] = mark + item[0][len(task[0]):]
+		}
+
+		content := w.blocks(item)
+		// tight list items are not wrapped in paragraphs
+		if strings.HasPrefix(content, "<p>") && strings.Count(content, "<p>") == 1 {
+			content = strings.Replace(content, "<p>", "", 1)
+			content = strings.Replace(content, "</p>", "", 1)
+		}
+		b.WriteString("<li>" + content + "</li>")
+	}
+
+	if ordered {
+		b.WriteString("</ol>")
+	} else {
+		b.WriteString("</ul>")
+	}
+	return b.String(), i
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func (w *htmlWriter) table(header, separator string, rows []string) string {
+	var aligns []string
+	for _, spec := range splitTableRow(separator) {
+		switch {
+		case strings.HasPrefix(spec, ":") && strings.HasSuffix(spec, ":"):
+			aligns = append(aligns, "center")
+		case strings.HasSuffix(spec, ":"):
+			aligns = append(aligns, "right")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+
+	writeRow := func(b *strings.Builder, line, cellTag string) {
+		b.WriteString("<tr>")
+		for i, cell := range splitTableRow(line) {
+			b.WriteString("<" + cellTag)
+			if i < len(aligns) && aligns[i] != "" {
+				b.WriteString(` style="text-align: ` + aligns[i] + `;"`)
+			}
+			b.WriteString(">" + w.inline(cell) + "</" + cellTag + ">")
+		}
+		b.WriteString("</tr>")
+	}
+
+	var b strings.Builder
+	b.WriteString("<table><tbody>")
+	writeRow(&b, header, "th")
+	for _, row := range rows {
+		writeRow(&b, row, "td")
+	}
+	b.WriteString("</tbody></table>")
+	return b.String()
+}
+
+// converts inline Markdown: emphasis, code, links, images, line breaks and inline HTML
+func (w *htmlWriter) inline(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		rest := s[i:]
+
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && rest[1] == '\n':
+			b.WriteString("<br/>")
+			i += 2
+
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_[]()<>#+-.!|~{}", rune(rest[1])):
+			b.WriteString(escapeHTML(rest[1:2]))
+			i += 2
+
+		case strings.HasPrefix(rest, "  \n"):
+			b.WriteString("<br/>")
+			i += len(rest) - len(strings.TrimLeft(rest, " ")) + 1
+
+		case rest[0] == '`':
+			run := len(rest) - len(strings.TrimLeft(rest, "`"))
+			marker := rest[:run]
+			end := strings.Index(rest[run:], marker)
+			if end < 0 {
+				b.WriteString(escapeHTML(marker))
+				i += run
+				continue
+			}
+			code := strings.TrimSpace(rest[run : run+end])
+			b.WriteString("<code>" + escapeHTML(code) + "</code>")
+			i += run + end + run
+
+		case strings.HasPrefix(rest, "!["):
+			alt, src, n, ok := parseLink(rest[1:])
+			if !ok {
+				b.WriteString("!")
+				i++
+				continue
+			}
+			b.WriteString(`<img src="` + escapeHTML(htmlImageSource(src)) + `"`)
+			if alt != "" {
+				b.WriteString(` alt="` + escapeHTML(unescapeMarkdown(alt)) + `"`)
+			}
+			b.WriteString("/>")
+			i += 1 + n
+
+		case rest[0] == '[':
+			text, href, n, ok := parseLink(rest)
+			if !ok {
+				b.WriteString("[")
+				i++
+				continue
+			}
+			if strings.HasPrefix(href, polarionLinkScheme) {
+				b.WriteString(w.rteLink(href))
+			} else {
+				b.WriteString(`<a href="` + escapeHTML(href) + `">` + w.inline(text) + "</a>")
+			}
+			i += n
+
+		case rest[0] == '<':
+			if m := autolinkRe.FindStringSubmatch(rest); m != nil {
+				b.WriteString(`<a href="` + escapeHTML(m[1]) + `">` + escapeHTML(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := inlineTagRe.FindString(rest); m != "" {
+				b.WriteString(m)
+				i += len(m)
+			} else {
+				b.WriteString("&lt;")
+				i++
+			}
+
+		case rest[0] == '&':
+			if m := entityRe.FindString(rest); m != "" {
+				b.WriteString(m)
+				i += len(m)
+			} else {
+				b.WriteString("&amp;")
+				i++
+			}
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if content, n, ok := delimited(s, i, rest[:2]); ok {
+				b.WriteString("<strong>" + w.inline(content) + "</strong>")
+				i += n
+				continue
+			}
+			b.WriteString(rest[:2])
+			i += 2
+
+		case strings.HasPrefix(rest, "~~"):
+			if content, n, ok := delimited(s, i, "~~"); ok {
+				b.WriteString("<span style=\"text-decoration: line-through;\">" + w.inline(content) + "</span>")
+				i += n
+				continue
+			}
+			b.WriteString("~~")
+			i += 2
+
+		case rest[0] == '*' || rest[0] == '_':
+			if content, n, ok := delimited(s, i, rest[:1]); ok {
+				b.WriteString("<em>" + w.inline(content) + "</em>")
+				i += n
+				continue
+			}
+			b.WriteString(rest[:1])
+			i++
+
+		case rest[0] == '>':
+			b.WriteString("&gt;")
+			i++
+
+		case rest[0] == '"':
+			b.WriteString("&quot;")
+			i++
+
+		default:
+			b.WriteByte(rest[0])
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// finds emphasis content between marker at position i and its closing marker
+func delimited(s string, i int, marker string) (string, int, bool) {
+	start := i + len(marker)
+	if start >= len(s) || s[start] == ' ' || s[start] == '\n' {
+		return "", 0, false
+	}
+	// intraword underscores are not emphasis
+	if marker[0] == '_' && i > 0 && isWordChar(s[i-1]) {
+		return "", 0, false
+	}
+
+	for j := start + 1; j+len(marker) <= len(s); j++ {
+		if s[j-1] == '\\' {
+			continue
+		}
+		if s[j:j+len(marker)] != marker || s[j-1] == ' ' {
+			continue
+		}
+		// "**" must not close single "*" emphasis
+		if len(marker) == 1 && j+1 < len(s) && s[j+1] == marker[0] {
+			j++
+			continue
+		}
+		if marker[0] == '_' && j+len(marker) < len(s) && isWordChar(s[j+len(marker)]) {
+			continue
+		}
+		return s[start:j], j + len(marker) - i, true
+	}
+	return "", 0, false
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// parses "[text](destination)", returns text, destination and consumed length
+func parseLink(s string) (string, string, int, bool) {
+	depth := 0
+	closing := -1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+		if closing >= 0 {
+			break
+		}
+	}
+	if closing < 0 || closing+1 >= len(s) || s[closing+1] != '(' {
+		return "", "", 0, false
+	}
+
+	// destination in angle brackets may contain spaces and parentheses,
+	// otherwise parentheses must be balanced: [l](http://x/a_(b))
+	searchFrom := closing + 2
+	if strings.HasPrefix(s[searchFrom:], "<") {
+		if gt := strings.IndexByte(s[searchFrom:], '>'); gt > 0 {
+			searchFrom += gt
+		}
+	}
+	end := closingParenthesis(s[searchFrom:])
+	if end < 0 {
+		return "", "", 0, false
+	}
+	end += searchFrom - (closing + 2)
+	destination := strings.TrimSpace(s[closing+2 : closing+2+end])
+	// optional title is dropped: [text](url "title")
+	if j := strings.Index(destination, " \""); j >= 0 {
+		destination = destination[:j]
+	}
+	destination = strings.Trim(destination, "<>")
+
+	return s[1:closing], destination, closing + 2 + end + 1, true
+}
+
+// index of parenthesis closing link destination, nested parentheses are skipped
+func closingParenthesis(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// maps Markdown image destination to Polarion image source, see markdownImageSource
+func htmlImageSource(src string) string {
+	escaped, ok := strings.CutPrefix(src, attachmentScheme)
+	if !ok {
+		return src
+	}
+	if id, err := url.PathUnescape(escaped); err == nil {
+		return attachmentScheme + id
+	}
+	return src
+}
+
+// polarion:PRJ/PRJ-1?option=short to Polarion work item reference macro
+func (w *htmlWriter) rteLink(href string) string {
+	reference, rawQuery, _ := strings.Cut(strings.TrimPrefix(href, polarionLinkScheme), "?")
+	query, _ := url.ParseQuery(rawQuery)
+
+	scope, itemID, scoped := strings.Cut(reference, "/")
+	if !scoped {
+		itemID, scope = scope, ""
+	}
+
+	attrs := map[string]string{
+		"class":          rteLinkClass,
+		"data-type":      "workItem",
+		"id":             "fake",
+		"data-item-id":   itemID,
+		"data-option-id": "long",
+	}
+	if scope != "" {
+		attrs["data-scope"] = scope
+	}
+	if option := query.Get("option"); option != "" {
+		attrs["data-option-id"] = option
+	}
+	if revision := query.Get("revision"); revision != "" {
+		attrs["data-revision"] = revision
+	}
+	if linkType := query.Get("type"); linkType != "" {
+		attrs["data-type"] = linkType
+	}
+
+	return renderHTML(&htmlNode{tag: "span", attrs: attrs})
+}
+
+func unescapeMarkdown(s string) string {
+	return markdownEscapeRe.ReplaceAllString(s, "$1")
+}
+
+func escapeHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
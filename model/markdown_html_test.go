@@ -0,0 +1,105 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		md       string
+		want     string
+		warnings []string
+	}{
+		{"paragraphs", "a\nb\n\nc", "<p>a\nb</p><p>c</p>", nil},
+		{"heading", "## Title ##", "<h2>Title</h2>", nil},
+		{
+			"emphasis",
+			"*em* **strong** ~~s~~ `code`",
+			`<p><em>em</em> <strong>strong</strong> <span style="text-decoration: line-through;">s</span> <code>code</code></p>`,
+			nil,
+		},
+		{"intraword underscores", "snake_case_word", "<p>snake_case_word</p>", nil},
+		{"line breaks", "a  \nb\\\nc", "<p>a<br/>b<br/>c</p>", nil},
+		{"escaped", `\*a\* \[b\]`, "<p>*a* [b]</p>", nil},
+		{"html specials", `a < b & c &amp; "q"`, "<p>a &lt; b &amp; c &amp; &quot;q&quot;</p>", nil},
+		{"link", "[l](http://x/y \"title\")", `<p><a href="http://x/y">l</a></p>`, nil},
+		{"link with parentheses", "[l](http://x/a_(b))", `<p><a href="http://x/a_(b)">l</a></p>`, nil},
+		{"link followed by parentheses", "[l](http://x/a_(b)) and (c)", `<p><a href="http://x/a_(b)">l</a> and (c)</p>`, nil},
+		{"link in angle brackets", "[l](<http://x/a b>)", `<p><a href="http://x/a b">l</a></p>`, nil},
+		{"unbalanced link", "[bad](http://x/(a)", "<p>[bad](http://x/(a)</p>", nil},
+		{"autolink", "<http://x/y>", `<p><a href="http://x/y">http://x/y</a></p>`, nil},
+		{
+			"work item reference",
+			"[PRJ-1](polarion:PRJ/PRJ-1?option=short)",
+			`<p><span class="polarion-rte-link" data-item-id="PRJ-1" data-option-id="short" data-scope="PRJ" data-type="workItem" id="fake"></span></p>`,
+			nil,
+		},
+		{"attachment image", "![d](attachment:1-diagram.png)", `<p><img src="attachment:1-diagram.png" alt="d"/></p>`, nil},
+		{
+			"escaped attachment image",
+			"![d](attachment:1-my%20image%20%281%29.png)",
+			`<p><img src="attachment:1-my image (1).png" alt="d"/></p>`,
+			nil,
+		},
+		{"image", "![](https://x/a.png)", `<p><img src="https://x/a.png"/></p>`, nil},
+		{
+			"lists",
+			"- a\n- b\n  - c\n\n3. x\n4. y",
+			`<ul><li>a</li><li>b<ul><li>c</li></ul></li></ul><ol start="3"><li>x</li><li>y</li></ol>`,
+			nil,
+		},
+		{"task list", "- [x] done\n- [ ] todo", "<ul><li>☑ done</li><li>☐ todo</li></ul>", []string{"task list checkboxes are converted to text"}},
+		{
+			"table",
+			"| a | b |\n|:-|-:|\n| 1 | 2 \\| 3 |",
+			`<table><tbody><tr><th>a</th><th style="text-align: right;">b</th></tr><tr><td>1</td><td style="text-align: right;">2 | 3</td></tr></tbody></table>`,
+			nil,
+		},
+		{"quote", "> quote\n> more", "<blockquote><p>quote\nmore</p></blockquote>", nil},
+		{"code block", "```\n<x>\n```", "<pre>&lt;x&gt;</pre>", nil},
+		{"rule", "a\n\n---\n\nb", "<p>a</p><hr/><p>b</p>", nil},
+		{"raw html", "<p class=\"x\">a</p>", `<p class="x">a</p>`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MarkdownToHTML(tt.md)
+			if got.Content != tt.want {
+				t.Errorf("content\n%q\nwant\n%q", got.Content, tt.want)
+			}
+			if !slices.Equal(got.Warnings, tt.warnings) {
+				t.Errorf("warnings %q, want %q", got.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	tests := []string{
+		"# Title",
+		"**bold** and *italic* with `code`",
+		"see [PRJ-1](polarion:PRJ/PRJ-1?option=short)",
+		"![diagram](attachment:1-my%20image.png)",
+		"[l](<http://x/a_(b)>)",
+		"- a\n- b",
+		"| a | b |\n| --- | --- |\n| 1 | 2 |",
+		`\# not a heading`,
+		`\> not a quote`,
+		`\- not a list`,
+		`\+ not a list`,
+		`1\. not a list`,
+		"line\\\n\\- after break",
+	}
+
+	for _, md := range tests {
+		t.Run(md, func(t *testing.T) {
+			html := MarkdownToHTML(md)
+			got := HTMLToMarkdown(html.Content)
+			if got.Content != md {
+				t.Errorf("round trip through %q gives\n%q\nwant\n%q", html.Content, got.Content, md)
+			}
+		})
+	}
+}
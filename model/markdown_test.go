@@ -0,0 +1,130 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		want     string
+		warnings []string
+	}{
+		{"paragraphs", "<p>one</p><p>two</p>", "one\n\ntwo", nil},
+		{"unclosed paragraphs", "<p>a<p>b", "a\n\nb", nil},
+		{"unclosed paragraphs with formatting", "<p><b>a</b><p>b<p>c", "**a**\n\nb\n\nc", nil},
+		{"unclosed list items", "<ul><li>a<li>b</ul>", "- a\n- b", nil},
+		{"list closes paragraph", "<p>text<ul><li>x</li></ul></p><p>after</p>", "text\n\n- x\n\nafter", nil},
+		{"nested list", "<ol start=\"3\"><li>a<ul><li>b</li></ul></li><li>c</li></ol>", "3. a\n   - b\n4. c", nil},
+		{"line break", "<p>a<br/>b</p>", "a\\\nb", nil},
+		{"heading and code", "<h2>Title</h2><pre>code\nx</pre>", "## Title\n\n```\ncode\nx\n```", nil},
+		{"emphasis", "<p><b>bold</b> <i>it</i> <s>gone</s> <code>c</code></p>", "**bold** *it* ~~gone~~ `c`", nil},
+		{
+			"styled spans",
+			`<span style="font-weight: bold;">b</span> <span style="text-decoration: line-through;">s</span>`,
+			"**b** ~~s~~", nil,
+		},
+		{"escaped specials", "<p>1 * 2 [x]</p>", `1 \* 2 \[x\]`, nil},
+		{"entities", "<p>a &lt; b&nbsp;&amp; c</p>", "a \\< b & c", nil},
+		{"heading marker", "<p># a</p>", `\# a`, nil},
+		{"quote marker", "<p>> a</p>", `\> a`, nil},
+		{"list markers", "<p>- a</p><p>+ b</p>", "\\- a\n\n\\+ b", nil},
+		{"ordered list marker", "<p>1. a</p>", `1\. a`, nil},
+		{"marker after line break", "<p>a<br/>- b</p>", "a\\\n\\- b", nil},
+		{"markers inside line", "<p>a - b # c 1. d</p>", "a - b # c 1. d", nil},
+		{"link", `<a href="http://x/y">l</a>`, "[l](http://x/y)", nil},
+		{"link with parentheses", `<a href="http://x/a_(b)">l</a>`, "[l](<http://x/a_(b)>)", nil},
+		{
+			"work item reference",
+			`<p>see <span class="polarion-rte-link" data-type="workItem" id="fake" data-scope="PRJ" data-item-id="PRJ-1" data-option-id="short"></span></p>`,
+			"see [PRJ-1](polarion:PRJ/PRJ-1?option=short)", nil,
+		},
+		{
+			"work item reference with defaults",
+			`<span class="polarion-rte-link" data-type="workItem" data-item-id="PRJ-2" data-option-id="long"></span>`,
+			"[PRJ-2](polarion:PRJ-2)", nil,
+		},
+		{
+			"other macro",
+			`<span class="polarion-rte-link" data-type="document" data-space="_default"></span>`,
+			`<span class="polarion-rte-link" data-space="_default" data-type="document"></span>`, nil,
+		},
+		{"attachment image", `<img src="attachment:1-diagram.png" alt="d"/>`, "![d](attachment:1-diagram.png)", nil},
+		{
+			"attachment image with spaces",
+			`<img src="attachment:1-my image (1).png"/>`,
+			"![](attachment:1-my%20image%20%281%29.png)", nil,
+		},
+		{
+			"attachment download URL",
+			`<img src="/polarion/wi-attachment/PRJ/PRJ-1/1-diagram%20x.png"/>`,
+			"![](attachment:1-diagram%20x.png)", nil,
+		},
+		{
+			"absolute attachment download URL",
+			`<img src="https://polarion.example.com/polarion/wi-attachment/PRJ/PRJ-1/2-a.png"/>`,
+			"![](attachment:2-a.png)", nil,
+		},
+		{
+			"external image",
+			`<img src="https://example.com/a.png" width="10"/>`,
+			"![](https://example.com/a.png)",
+			[]string{"image size and style are dropped"},
+		},
+		{
+			"table",
+			"<table><tr><td>a</td><td>b|c</td></tr><tr><td>1</td><td>2</td></tr></table>",
+			"| a | b\\|c |\n| --- | --- |\n| 1 | 2 |",
+			[]string{"first table row is converted to header"},
+		},
+		{
+			"table with header",
+			"<table><tbody><tr><th>a</th></tr><tr><td colspan=\"2\">x</td></tr></tbody></table>",
+			"| a |\n| --- |\n| x |",
+			[]string{"merged table cells are split"},
+		},
+		{"underline", "<u>under</u>", "under", []string{"underline is not supported in Markdown"}},
+		{"paragraph style", `<p style="color:red">x</p>`, "x", []string{"inline styles (colors, fonts, alignment) are dropped"}},
+		{"unknown element", "<blink>x</blink>", "x", []string{"unsupported element <blink> converted to text"}},
+		{"blockquote", "<blockquote><p>a</p><p>b</p></blockquote>", "> a\n>\n> b", nil},
+		{"rule", "<p>a</p><hr/><p>b</p>", "a\n\n---\n\nb", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HTMLToMarkdown(tt.html)
+			if got.Content != tt.want {
+				t.Errorf("content\n%q\nwant\n%q", got.Content, tt.want)
+			}
+			if !slices.Equal(got.Warnings, tt.warnings) {
+				t.Errorf("warnings %q, want %q", got.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestTextMarkdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      *Text
+		want      string
+		wantLossy bool
+	}{
+		{"nil", nil, "", false},
+		{"plain", NewPlainText("a *b*"), `a \*b\*`, false},
+		{"plain block markers", NewPlainText("# a\n2. b"), "\\# a\n2\\. b", false},
+		{"html", NewHTMLText("<b>a</b>"), "**a**", false},
+		{"lossy", &Text{Type: TextHTML, Content: "a", ContentLossy: true}, "a", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.text.Markdown()
+			if got.Content != tt.want || got.Lossy() != tt.wantLossy {
+				t.Errorf("Markdown() = %q lossy %v, want %q lossy %v", got.Content, got.Lossy(), tt.want, tt.wantLossy)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Package model contains idiomatic Go representations of Polarion objects
+// and conversions to and from types generated by gowsdl.
+package model
+
+import (
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+	"github.com/hooklift/gowsdl/soap"
+)
+
+// Polarion text content types
+const (
+	TextHTML  = "text/html"
+	TextPlain = "text/plain"
+)
+
+// FromXSDDateTime converts soap date time, zero value stays zero time.Time
+func FromXSDDateTime(dt soap.XSDDateTime) time.Time {
+	if dt == (soap.XSDDateTime{}) {
+		return time.Time{}
+	}
+	return dt.ToGoTime()
+}
+
+// ToXSDDateTime converts time.Time, zero time is omitted when marshalled
+func ToXSDDateTime(t time.Time) soap.XSDDateTime {
+	if t.IsZero() {
+		return soap.XSDDateTime{}
+	}
+	return soap.CreateXsdDateTime(t, true)
+}
+
+// FromXSDDate converts soap date, zero value stays zero time.Time
+func FromXSDDate(d soap.XSDDate) time.Time {
+	if d == (soap.XSDDate{}) {
+		return time.Time{}
+	}
+	return d.ToGoTime()
+}
+
+// ToXSDDate converts time.Time to date without time zone, as Polarion uses for dates
+func ToXSDDate(t time.Time) soap.XSDDate {
+	if t.IsZero() {
+		return soap.XSDDate{}
+	}
+	return soap.CreateXsdDate(t, false)
+}
+
+// EnumID returns ID of enum option or empty string if not set
+func EnumID(opt *tracker_ws.EnumOptionId) string {
+	if opt == nil || opt.Id == nil {
+		return ""
+	}
+	return *opt.Id
+}
+
+// NewEnumID creates enum option ID, nil for empty ID
+func NewEnumID(id string) *tracker_ws.EnumOptionId {
+	if id == "" {
+		return nil
+	}
+	return &tracker_ws.EnumOptionId{Id: &id}
+}
+
+// URI returns string value of SubterraURI or empty string if not set
+func URI(uri *tracker_ws.SubterraURI) string {
+	if uri == nil {
+		return ""
+	}
+	return string(*uri)
+}
+
+// NewURI creates SubterraURI, nil for empty URI
+func NewURI(uri string) *tracker_ws.SubterraURI {
+	if uri == "" {
+		return nil
+	}
+	u := tracker_ws.SubterraURI(uri)
+	return &u
+}
@@ -0,0 +1,53 @@
+package model
+
+import "github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+
+// Table is value of table custom field, each row has value for every key (column)
+type Table struct {
+	Keys []string
+	Rows [][]*Text
+}
+
+func TableFromWS(t *tracker_ws.Table) *Table {
+	if t == nil {
+		return nil
+	}
+
+	table := &Table{}
+	if t.Keys != nil {
+		table.Keys = t.Keys.Astring
+	}
+	if t.Rows != nil {
+		for _, row := range t.Rows.Row {
+			var values []*Text
+			if row != nil && row.Values != nil {
+				for _, value := range row.Values.Text {
+					values = append(values, TextFromWS(value))
+				}
+			}
+			table.Rows = append(table.Rows, values)
+		}
+	}
+
+	return table
+}
+
+func (t *Table) ToWS() *tracker_ws.Table {
+	if t == nil {
+		return nil
+	}
+
+	table := &tracker_ws.Table{
+		Keys: &tracker_ws.ArrayOfstring{Astring: t.Keys},
+		Rows: &tracker_ws.ArrayOfRow{},
+	}
+	for _, row := range t.Rows {
+		values := &tracker_ws.ArrayOfText{}
+		for _, value := range row {
+			values.Text = append(values.Text, value.ToWS())
+		}
+		table.Rows.Row = append(table.Rows.Row, &tracker_ws.Row{Values: values})
+	}
+
+	return table
+}
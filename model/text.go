@@ -0,0 +1,71 @@
+package model
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// Text is rich text content (descriptions, comments), either HTML or plain
+type Text struct {
+	Type    string
+	Content string
+
+	// true if Polarion could not represent content in requested type without loss
+	ContentLossy bool
+}
+
+func NewHTMLText(content string) *Text {
+	return &Text{Type: TextHTML, Content: content}
+}
+
+func NewPlainText(content string) *Text {
+	return &Text{Type: TextPlain, Content: content}
+}
+
+func TextFromWS(t *tracker_ws.Text) *Text {
+	if t == nil {
+		return nil
+	}
+	return &Text{
+		Type:         t.Type_,
+		Content:      t.Content,
+		ContentLossy: t.ContentLossy,
+	}
+}
+
+func (t *Text) ToWS() *tracker_ws.Text {
+	if t == nil {
+		return nil
+	}
+	return &tracker_ws.Text{
+		Type_:        t.Type,
+		Content:      t.Content,
+		ContentLossy: t.ContentLossy,
+	}
+}
+
+func (t *Text) IsHTML() bool {
+	return t != nil && t.Type == TextHTML
+}
+
+var (
+	htmlLineBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+	htmlTagRe       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Plain returns content without HTML markup
+func (t *Text) Plain() string {
+	if t == nil {
+		return ""
+	}
+	if !t.IsHTML() {
+		return t.Content
+	}
+
+	plain := htmlLineBreakRe.ReplaceAllString(t.Content, "\n")
+	plain = htmlTagRe.ReplaceAllString(plain, "")
+	return strings.TrimSpace(html.UnescapeString(plain))
+}
@@ -0,0 +1,67 @@
+package model
+
+import "github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+
+type User struct {
+	URI   string
+	ID    string
+	Name  string
+	Email string
+
+	// original value keeps fields not exposed by User (description, votes, watches...)
+	raw *tracker_ws.User
+}
+
+func UserFromWS(u *tracker_ws.User) *User {
+	if u == nil {
+		return nil
+	}
+	return &User{
+		URI:   URI(u.Uri),
+		ID:    u.Id,
+		Name:  u.Name,
+		Email: u.Email,
+		raw:   u,
+	}
+}
+
+func (u *User) ToWS() *tracker_ws.User {
+	if u == nil {
+		return nil
+	}
+
+	wsUser := tracker_ws.User{}
+	if u.raw != nil {
+		wsUser = *u.raw
+	}
+	wsUser.Uri = NewURI(u.URI)
+	wsUser.Id = u.ID
+	wsUser.Name = u.Name
+	wsUser.Email = u.Email
+
+	return &wsUser
+}
+
+func usersFromWS(users *tracker_ws.ArrayOfUser) []User {
+	if users == nil {
+		return nil
+	}
+	result := make([]User, 0, len(users.User))
+	for _, u := range users.User {
+		if u != nil {
+			result = append(result, *UserFromWS(u))
+		}
+	}
+	return result
+}
+
+func usersToWS(users []User) *tracker_ws.ArrayOfUser {
+	if users == nil {
+		return nil
+	}
+	result := &tracker_ws.ArrayOfUser{User: make([]*tracker_ws.User, 0, len(users))}
+	for i := range users {
+		result.User = append(result.User, users[i].ToWS())
+	}
+	return result
+}
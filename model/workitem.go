@@ -0,0 +1,262 @@
+package model
+
+import (
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// WorkItem is tracker_ws.WorkItem with unwrapped values:
+// enum options are plain IDs, dates are time.Time and nil arrays are nil slices.
+// Fields without idiomatic counterpart keep generated types.
+// CustomFields are not converted by WorkItemFromWS and ToWS because generated
+// values lose their XSI type, polarion_wsdl.WorkItemModel and WorkItemFromModel
+// convert them together with the rest of the work item.
+type WorkItem struct {
+	URI          string
+	Unresolvable bool
+
+	ID             string
+	Title          string
+	Type           string
+	Status         string
+	PreviousStatus string
+	Resolution     string
+	Severity       string
+	Priority       string
+	Description    *Text
+
+	Author    *User
+	Assignees []User
+
+	Created      time.Time
+	Updated      time.Time
+	ResolvedOn   time.Time
+	DueDate      time.Time
+	PlannedStart time.Time
+	PlannedEnd   time.Time
+
+	InitialEstimate   string
+	RemainingEstimate string
+	TimeSpent         string
+
+	Links        []Link
+	DerivedLinks []Link
+	Hyperlinks   []Hyperlink
+	PlannedIn    []string
+
+	ModuleURI     string
+	Location      string
+	OutlineNumber string
+
+	Project                   *tracker_ws.Project
+	TimePoint                 *tracker_ws.TimePoint
+	Approvals                 *tracker_ws.ArrayOfApproval
+	Attachments               *tracker_ws.ArrayOfAttachment
+	Categories                *tracker_ws.ArrayOfCategory
+	Comments                  *tracker_ws.ArrayOfComment
+	ExternallyLinkedWorkItems *tracker_ws.ArrayOfExternallyLinkedWorkItem
+	LinkedOslcResources       *tracker_ws.ArrayOfLinkedOslcResource
+	LinkedRevisions           *tracker_ws.ArrayOfRevision
+	LinkedRevisionsDerived    *tracker_ws.ArrayOfRevision
+	PlanningConstraints       *tracker_ws.ArrayOfPlanningConstraint
+	WorkRecords               *tracker_ws.ArrayOfWorkRecord
+
+	CustomFields map[string]CustomField
+}
+
+// CustomField is decoded value of work item custom field.
+// Type is XSI type of the value as received from Polarion (e.g. "xsd:date"),
+// it is used to encode changed value back and can be empty for new fields.
+type CustomField struct {
+	Type  string
+	Value any
+}
+
+// ProjectID returns ID of work item project or empty string if project was not fetched
+func (wi *WorkItem) ProjectID() string {
+	if wi.Project == nil {
+		return ""
+	}
+	return wi.Project.Id
+}
+
+func WorkItemFromWS(wi *tracker_ws.WorkItem) *WorkItem {
+	if wi == nil {
+		return nil
+	}
+
+	item := &WorkItem{
+		URI:          URI(wi.Uri),
+		Unresolvable: wi.Unresolvable,
+
+		ID:             wi.Id,
+		Title:          wi.Title,
+		Type:           EnumID(wi.Type_),
+		Status:         EnumID(wi.Status),
+		PreviousStatus: EnumID(wi.PreviousStatus),
+		Resolution:     EnumID(wi.Resolution),
+		Severity:       EnumID(wi.Severity),
+		Description:    TextFromWS(wi.Description),
+
+		Author:    UserFromWS(wi.Author),
+		Assignees: usersFromWS(wi.Assignee),
+
+		Created:      FromXSDDateTime(wi.Created),
+		Updated:      FromXSDDateTime(wi.Updated),
+		ResolvedOn:   FromXSDDateTime(wi.ResolvedOn),
+		DueDate:      FromXSDDate(wi.DueDate),
+		PlannedStart: FromXSDDateTime(wi.PlannedStart),
+		PlannedEnd:   FromXSDDateTime(wi.PlannedEnd),
+
+		InitialEstimate:   durationString(wi.InitialEstimate),
+		RemainingEstimate: durationString(wi.RemainingEstimate),
+		TimeSpent:         durationString(wi.TimeSpent),
+
+		Links:        linksFromWS(wi.LinkedWorkItems),
+		DerivedLinks: linksFromWS(wi.LinkedWorkItemsDerived),
+		Hyperlinks:   hyperlinksFromWS(wi.Hyperlinks),
+		PlannedIn:    urisFromWS(wi.PlannedInURIs),
+
+		ModuleURI:     URI(wi.ModuleURI),
+		OutlineNumber: wi.OutlineNumber,
+
+		Project:                   wi.Project,
+		TimePoint:                 wi.TimePoint,
+		Approvals:                 wi.Approvals,
+		Attachments:               wi.Attachments,
+		Categories:                wi.Categories,
+		Comments:                  wi.Comments,
+		ExternallyLinkedWorkItems: wi.ExternallyLinkedWorkItems,
+		LinkedOslcResources:       wi.LinkedOslcResources,
+		LinkedRevisions:           wi.LinkedRevisions,
+		LinkedRevisionsDerived:    wi.LinkedRevisionsDerived,
+		PlanningConstraints:       wi.PlanningConstraints,
+		WorkRecords:               wi.WorkRecords,
+	}
+
+	if wi.Priority != nil {
+		item.Priority = EnumID(wi.Priority.EnumOptionId)
+	}
+	if wi.Location != nil {
+		item.Location = string(*wi.Location)
+	}
+
+	return item
+}
+
+func (wi *WorkItem) ToWS() *tracker_ws.WorkItem {
+	if wi == nil {
+		return nil
+	}
+
+	item := &tracker_ws.WorkItem{
+		Uri:          NewURI(wi.URI),
+		Unresolvable: wi.Unresolvable,
+
+		Id:             wi.ID,
+		Title:          wi.Title,
+		Type_:          NewEnumID(wi.Type),
+		Status:         NewEnumID(wi.Status),
+		PreviousStatus: NewEnumID(wi.PreviousStatus),
+		Resolution:     NewEnumID(wi.Resolution),
+		Severity:       NewEnumID(wi.Severity),
+		Description:    wi.Description.ToWS(),
+
+		Author:   wi.Author.ToWS(),
+		Assignee: usersToWS(wi.Assignees),
+
+		Created:      ToXSDDateTime(wi.Created),
+		Updated:      ToXSDDateTime(wi.Updated),
+		ResolvedOn:   ToXSDDateTime(wi.ResolvedOn),
+		DueDate:      ToXSDDate(wi.DueDate),
+		PlannedStart: ToXSDDateTime(wi.PlannedStart),
+		PlannedEnd:   ToXSDDateTime(wi.PlannedEnd),
+
+		InitialEstimate:   newDuration(wi.InitialEstimate),
+		RemainingEstimate: newDuration(wi.RemainingEstimate),
+		TimeSpent:         newDuration(wi.TimeSpent),
+
+		LinkedWorkItems:        linksToWS(wi.Links),
+		LinkedWorkItemsDerived: linksToWS(wi.DerivedLinks),
+		Hyperlinks:             hyperlinksToWS(wi.Hyperlinks),
+		PlannedInURIs:          urisToWS(wi.PlannedIn),
+
+		ModuleURI:     NewURI(wi.ModuleURI),
+		OutlineNumber: wi.OutlineNumber,
+
+		Project:                   wi.Project,
+		TimePoint:                 wi.TimePoint,
+		Approvals:                 wi.Approvals,
+		Attachments:               wi.Attachments,
+		Categories:                wi.Categories,
+		Comments:                  wi.Comments,
+		ExternallyLinkedWorkItems: wi.ExternallyLinkedWorkItems,
+		LinkedOslcResources:       wi.LinkedOslcResources,
+		LinkedRevisions:           wi.LinkedRevisions,
+		LinkedRevisionsDerived:    wi.LinkedRevisionsDerived,
+		PlanningConstraints:       wi.PlanningConstraints,
+		WorkRecords:               wi.WorkRecords,
+	}
+
+	if wi.Priority != "" {
+		item.Priority = &tracker_ws.PriorityOptionId{EnumOptionId: NewEnumID(wi.Priority)}
+	}
+	if wi.Location != "" {
+		location := tracker_ws.Location(wi.Location)
+		item.Location = &location
+	}
+
+	return item
+}
+
+func WorkItemsFromWS(items []*tracker_ws.WorkItem) []*WorkItem {
+	result := make([]*WorkItem, 0, len(items))
+	for _, wi := range items {
+		if wi != nil {
+			result = append(result, WorkItemFromWS(wi))
+		}
+	}
+	return result
+}
+
+func durationString(d *tracker_ws.Duration) string {
+	if d == nil {
+		return ""
+	}
+	return string(*d)
+}
+
+func newDuration(d string) *tracker_ws.Duration {
+	if d == "" {
+		return nil
+	}
+	duration := tracker_ws.Duration(d)
+	return &duration
+}
+
+func urisFromWS(uris *tracker_ws.ArrayOfSubterraURI) []string {
+	if uris == nil {
+		return nil
+	}
+	result := make([]string, 0, len(uris.SubterraURI))
+	for _, uri := range uris.SubterraURI {
+		if uri != nil {
+			result = append(result, string(*uri))
+		}
+	}
+	return result
+}
+
+func urisToWS(uris []string) *tracker_ws.ArrayOfSubterraURI {
+	if uris == nil {
+		return nil
+	}
+	result := &tracker_ws.ArrayOfSubterraURI{
+		SubterraURI: make([]*tracker_ws.SubterraURI, 0, len(uris)),
+	}
+	for _, uri := range uris {
+		result.SubterraURI = append(result.SubterraURI, NewURI(uri))
+	}
+	return result
+}
@@ -0,0 +1,290 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// PlanningConstraintType is ID of planning constraint option
+type PlanningConstraintType string
+
+const (
+	MustStartOn        PlanningConstraintType = "mustStartOn"
+	StartNoEarlierThan PlanningConstraintType = "startNoEarlierThan"
+	FinishNoLaterThan  PlanningConstraintType = "finishNoLaterThan"
+)
+
+// enum of planning constraint types
+const planningConstraintEnumID = "planning-constraint"
+
+// PlanningConstraint restricts when work item can be scheduled, Date is start or end
+// of work item depending on Type
+type PlanningConstraint struct {
+	Type PlanningConstraintType
+	Date time.Time
+}
+
+// TimePoint is milestone or release of project
+type TimePoint struct {
+	URI                  string
+	ID                   string
+	Name                 string
+	Date                 time.Time
+	EarliestPlannedStart time.Time
+	Closed               bool
+	Description          *model.Text
+
+	// work items planned to time point, filled only by TimePointsWithItems
+	Items []*model.WorkItem
+}
+
+// LateItem is unresolved work item which does not meet date of its time point
+type LateItem struct {
+	Item      *model.WorkItem
+	TimePoint *TimePoint
+
+	// time point date passed, otherwise item is only planned to end after it
+	Overdue bool
+}
+
+// fields of work items planned to time points
+var plannedItemFields = []string{
+	"id", "title", "status", "resolvedOn", "timePoint", "plannedStart", "plannedEnd", "planningConstraints",
+}
+
+func timePointFromWS(tp *tracker_ws.TimePoint) *TimePoint {
+	if tp == nil {
+		return nil
+	}
+	return &TimePoint{
+		URI:                  model.URI(tp.Uri),
+		ID:                   tp.Id,
+		Name:                 tp.Name,
+		Date:                 model.FromXSDDate(tp.Time),
+		EarliestPlannedStart: model.FromXSDDate(tp.EarliestPlannedStart),
+		Closed:               tp.Closed,
+		Description:          model.TextFromWS(tp.Description),
+	}
+}
+
+// TimePoints returns time points of project ordered by date
+func (p *Polarion) TimePoints(ctx context.Context, projectID string) ([]*TimePoint, error) {
+	req := tracker_ws.GetTimepoints{
+		ProjectId: projectID,
+	}
+	resp, err := p.TrackerWS.GetTimepointsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time points of project '%s': %v", projectID, err)
+	}
+
+	var timePoints []*TimePoint
+	for _, tp := range resp.GetTimepointsReturn {
+		if timePoint := timePointFromWS(tp); timePoint != nil {
+			timePoints = append(timePoints, timePoint)
+		}
+	}
+	slices.SortStableFunc(timePoints, func(a, b *TimePoint) int {
+		return a.Date.Compare(b.Date)
+	})
+	return timePoints, nil
+}
+
+// TimePointsWithItems returns time points of project with work items planned to them
+func (p *Polarion) TimePointsWithItems(ctx context.Context, projectID string) ([]*TimePoint, error) {
+	timePoints, err := p.TimePoints(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("project.id:%s AND HAS_VALUE:timePoint", luceneQuote(projectID))
+	req := tracker_ws.QueryWorkItems{
+		Query:  query,
+		Sort:   "id",
+		Fields: plannedItemFields,
+	}
+	resp, err := p.TrackerWS.QueryWorkItemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work items '%s': %v", query, err)
+	}
+
+	for _, wi := range resp.QueryWorkItemsReturn {
+		if wi == nil || wi.TimePoint == nil {
+			continue
+		}
+		// time point of work item may be unresolved reference with URI only
+		i := slices.IndexFunc(timePoints, func(tp *TimePoint) bool {
+			return tp.URI == model.URI(wi.TimePoint.Uri) || (tp.ID != "" && tp.ID == wi.TimePoint.Id)
+		})
+		if i >= 0 {
+			timePoints[i].Items = append(timePoints[i].Items, model.WorkItemFromWS(wi))
+		}
+	}
+	return timePoints, nil
+}
+
+// MoveToTimePoint plans work items to time point of project, empty time point ID unplans them.
+// Failures do not stop remaining items and are returned joined.
+func (p *Polarion) MoveToTimePoint(
+	ctx context.Context,
+	projectID string,
+	timePointID string,
+	uris ...*tracker_ws.SubterraURI,
+) error {
+	var timePoint *tracker_ws.TimePoint
+	if timePointID != "" {
+		timePoints, err := p.TimePoints(ctx, projectID)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(timePoints))
+		for _, tp := range timePoints {
+			ids = append(ids, tp.ID)
+			if tp.ID == timePointID {
+				timePoint = &tracker_ws.TimePoint{Uri: model.NewURI(tp.URI), Id: tp.ID}
+			}
+		}
+		if err := checkProjectID("time point", timePointID, projectID, ids); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, uri := range uris {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		// nil time point is cleared with SetFieldsNull
+		if _, err := p.UpdateWorkItem(ctx, uri, FieldMask{"timePoint": timePoint}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PlanningConstraints returns planning constraints of work item
+func (p *Polarion) PlanningConstraints(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]PlanningConstraint, error) {
+	wi, err := p.workItemFields(ctx, workItemURI, "planningConstraints")
+	if err != nil {
+		return nil, err
+	}
+	return planningConstraintsFromWS(wi.PlanningConstraints), nil
+}
+
+func planningConstraintsFromWS(constraints *tracker_ws.ArrayOfPlanningConstraint) []PlanningConstraint {
+	if constraints == nil {
+		return nil
+	}
+
+	var result []PlanningConstraint
+	for _, c := range constraints.PlanningConstraint {
+		if c != nil {
+			result = append(result, PlanningConstraint{
+				Type: PlanningConstraintType(model.EnumID(c.Constraint)),
+				Date: model.FromXSDDateTime(c.Date),
+			})
+		}
+	}
+	return result
+}
+
+// AddPlanningConstraint adds planning constraint to work item, e.g. MustStartOn given date.
+// Type must be option of planning constraint enum of work item project.
+func (p *Polarion) AddPlanningConstraint(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	constraint PlanningConstraint,
+) error {
+	if constraint.Type == "" || constraint.Date.IsZero() {
+		return fmt.Errorf("type and date are required for planning constraint")
+	}
+	if err := p.validatePlanningConstraintType(ctx, workItemURI, constraint.Type); err != nil {
+		return err
+	}
+
+	req := tracker_ws.AddPlaningContraint{
+		WorkitemURI: workItemURI,
+		Date:        model.ToXSDDateTime(constraint.Date),
+		Constraint:  model.NewEnumID(string(constraint.Type)),
+	}
+	resp, err := p.TrackerWS.AddPlaningContraintContext(ctx, &req)
+	if err != nil {
+		return fmt.Errorf("failed to add planning constraint %s to work item %s: %v", constraint.Type, model.URI(workItemURI), err)
+	}
+	if !resp.AddPlaningContraintReturn {
+		return fmt.Errorf("planning constraint %s was not added to work item %s", constraint.Type, model.URI(workItemURI))
+	}
+	return nil
+}
+
+func (p *Polarion) validatePlanningConstraintType(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	constraintType PlanningConstraintType,
+) error {
+	wi, err := p.workItemFields(ctx, workItemURI, "project")
+	if err != nil {
+		return err
+	}
+	projectID := projectIDOf(wi.Project)
+	return p.checkEnumOption(ctx, projectID, planningConstraintEnumID, "planning constraint type", string(constraintType))
+}
+
+// RemovePlanningConstraint removes planning constraint with same type and date from work item
+func (p *Polarion) RemovePlanningConstraint(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	constraint PlanningConstraint,
+) error {
+	req := tracker_ws.RemovePlaningConstraint{
+		WorkitemURI: workItemURI,
+		Date:        model.ToXSDDateTime(constraint.Date),
+		Constraint:  model.NewEnumID(string(constraint.Type)),
+	}
+	resp, err := p.TrackerWS.RemovePlaningConstraintContext(ctx, &req)
+	if err != nil {
+		return fmt.Errorf("failed to remove planning constraint %s from work item %s: %v", constraint.Type, model.URI(workItemURI), err)
+	}
+	if !resp.RemovePlaningConstraintReturn {
+		return fmt.Errorf("planning constraint %s was not found in work item %s", constraint.Type, model.URI(workItemURI))
+	}
+	return nil
+}
+
+// LateItems returns unresolved work items of project whose time point date passed at asOf
+// or which are planned to end after date of their time point. Closed time points are skipped.
+func (p *Polarion) LateItems(ctx context.Context, projectID string, asOf time.Time) ([]LateItem, error) {
+	timePoints, err := p.TimePointsWithItems(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lateItems(timePoints, asOf), nil
+}
+
+func lateItems(timePoints []*TimePoint, asOf time.Time) []LateItem {
+	var late []LateItem
+	for _, tp := range timePoints {
+		if tp.Closed || tp.Date.IsZero() {
+			continue
+		}
+		// time point date is the whole day
+		deadline := tp.Date.AddDate(0, 0, 1)
+		for _, item := range tp.Items {
+			if !item.ResolvedOn.IsZero() {
+				continue
+			}
+			overdue := !asOf.Before(deadline)
+			if overdue || item.PlannedEnd.After(deadline) {
+				late = append(late, LateItem{Item: item, TimePoint: tp, Overdue: overdue})
+			}
+		}
+	}
+	return late
+}
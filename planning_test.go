@@ -0,0 +1,100 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+func TestLateItems(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	// end of day 10 of time point date
+	endOfDay := day(11)
+
+	tests := []struct {
+		name        string
+		asOf        time.Time
+		closed      bool
+		item        model.WorkItem
+		wantLate    bool
+		wantOverdue bool
+	}{
+		{name: "before date", asOf: day(9), item: model.WorkItem{ID: "A"}},
+		{name: "during date", asOf: day(10).Add(23 * time.Hour), item: model.WorkItem{ID: "A"}},
+		{name: "exactly at end of date", asOf: endOfDay, item: model.WorkItem{ID: "A"}, wantLate: true, wantOverdue: true},
+		{name: "after date", asOf: day(12), item: model.WorkItem{ID: "A"}, wantLate: true, wantOverdue: true},
+		{name: "resolved", asOf: day(12), item: model.WorkItem{ID: "A", ResolvedOn: day(11)}},
+		{name: "closed time point", asOf: day(12), closed: true, item: model.WorkItem{ID: "A"}},
+		{name: "planned end after date", asOf: day(1), item: model.WorkItem{ID: "A", PlannedEnd: day(12)}, wantLate: true},
+		{name: "planned end at end of date", asOf: day(1), item: model.WorkItem{ID: "A", PlannedEnd: endOfDay}},
+		{name: "resolved with planned end after date", asOf: day(1), item: model.WorkItem{ID: "A", PlannedEnd: day(12), ResolvedOn: day(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := tt.item
+			tp := &TimePoint{ID: "release", Date: day(10), Closed: tt.closed, Items: []*model.WorkItem{&item}}
+
+			late := lateItems([]*TimePoint{tp}, tt.asOf)
+			if !tt.wantLate {
+				if len(late) != 0 {
+					t.Errorf("lateItems() = %+v, want none", late)
+				}
+				return
+			}
+			if len(late) != 1 || late[0].Item != &item || late[0].TimePoint != tp {
+				t.Fatalf("lateItems() = %+v, want item %s", late, item.ID)
+			}
+			if late[0].Overdue != tt.wantOverdue {
+				t.Errorf("overdue = %v, want %v", late[0].Overdue, tt.wantOverdue)
+			}
+		})
+	}
+}
+
+func TestLateItemsWithoutDate(t *testing.T) {
+	tp := &TimePoint{ID: "backlog", Items: []*model.WorkItem{{ID: "A"}}}
+	if late := lateItems([]*TimePoint{tp}, time.Now()); len(late) != 0 {
+		t.Errorf("lateItems() = %+v, want none for time point without date", late)
+	}
+}
+
+func TestPlanningConstraintsFromWS(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want []PlanningConstraint
+	}{
+		{"none", "", nil},
+		{
+			"constraints",
+			`<planningConstraints>
+				<PlanningConstraint><constraint><id>mustStartOn</id></constraint><date>2024-05-10T08:00:00Z</date></PlanningConstraint>
+				<PlanningConstraint><constraint><id>finishNoLaterThan</id></constraint><date>2024-05-20T17:00:00Z</date></PlanningConstraint>
+			</planningConstraints>`,
+			[]PlanningConstraint{
+				{Type: MustStartOn, Date: time.Date(2024, 5, 10, 8, 0, 0, 0, time.UTC)},
+				{Type: FinishNoLaterThan, Date: time.Date(2024, 5, 20, 17, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wi tracker_ws.WorkItem
+			if err := xml.Unmarshal([]byte("<workItem>"+tt.xml+"</workItem>"), &wi); err != nil {
+				t.Fatal(err)
+			}
+			got := planningConstraintsFromWS(wi.PlanningConstraints)
+			if len(got) != len(tt.want) {
+				t.Fatalf("planningConstraintsFromWS() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Type != tt.want[i].Type || !got[i].Date.Equal(tt.want[i].Date) {
+					t.Errorf("constraint %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
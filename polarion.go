@@ -1,12 +1,15 @@
 package polarion_wsdl
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/AVaitkunas/polarion-wsdl/model"
 	"github.com/AVaitkunas/polarion-wsdl/session_ws"
 	"github.com/AVaitkunas/polarion-wsdl/test_ws"
 	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
@@ -41,12 +44,27 @@ type Polarion struct {
 	TrackerWS     tracker_ws.TrackerWebService
 	TestClient    *soap.Client
 	TestWS        test_ws.TestManagementWebService
+
+	// used for raw requests which are streamed instead of going through soap clients
+	trackerEndpoint string
+	projectEndpoint string
+	sessionHeader   *sessionHeader
+
+	// timeout of soap clients, also applied to raw requests
+	timeout time.Duration
+
+	// custom field keys used to validate requested work item fields
+	customFieldKeys customFieldKeyCache
+	dayLength       dayLengthCache
+	workflows       workflowCache
+	enums           enumCache
 }
 
 func NewPolarion(polarion_url, username, accessToken string, timeout time.Duration) (*Polarion, error) {
 	sessionEndpoint := fmt.Sprintf("%s/%s", polarion_url, "polarion/ws/services/SessionWebService?wsdl")
 	trackerEndpoint := fmt.Sprintf("%s/%s", polarion_url, "polarion/ws/services/TrackerWebService?wsdl")
 	testsEndpoint := fmt.Sprintf("%s/%s", polarion_url, "polarion/ws/services/TestManagementWebService?wsdl")
+	projectEndpoint := fmt.Sprintf("%s/%s", polarion_url, "polarion/ws/services/ProjectWebService?wsdl")
 
 	httpClient := &http.Client{
 		Transport: &http.Transport{
@@ -98,6 +116,11 @@ func NewPolarion(polarion_url, username, accessToken string, timeout time.Durati
 		TrackerWS:     trackerWS,
 		TestClient:    testClient,
 		TestWS:        testWS,
+
+		trackerEndpoint: trackerEndpoint,
+		projectEndpoint: projectEndpoint,
+		sessionHeader:   sessionHeader,
+		timeout:         timeout,
 	}
 
 	return polarion, nil
@@ -142,6 +165,9 @@ func (p *Polarion) QueryWorkItems(
 	}
 
 	if len(fields) > 0 {
+		if err := p.ValidateWorkItemFields(context.Background(), queryProjectID(query), fields); err != nil {
+			return nil, err
+		}
 		req.Fields = fields
 		req.Sort = sortField
 		if sortField == "" {
@@ -163,6 +189,10 @@ func (p *Polarion) QueryWorkItemsBySQL(
 	sqlQuery string,
 	fields []string,
 ) ([]*tracker_ws.WorkItem, error) {
+	if err := p.ValidateWorkItemFields(context.Background(), "", fields); err != nil {
+		return nil, err
+	}
+
 	req := tracker_ws.QueryWorkItemsBySQL{
 		SqlQuery: sqlQuery,
 		Fields:   fields,
@@ -259,6 +289,10 @@ func (p *Polarion) QueryTestRuns(
 	query, sortField string,
 	fields []string,
 ) ([]*test_ws.TestRun, error) {
+	if err := validateFields("test run", fields, testRunFieldNames, nil, true); err != nil {
+		return nil, err
+	}
+
 	req := test_ws.SearchTestRunsWithFields{
 		Query:  query,
 		Sort:   sortField,
@@ -275,6 +309,10 @@ func (p *Polarion) QueryWorkItemsInBaseline(
 	baselineRevision, query, sort string,
 	fields []string,
 ) ([]*tracker_ws.WorkItem, error) {
+	if err := p.ValidateWorkItemFields(context.Background(), queryProjectID(query), fields); err != nil {
+		return nil, err
+	}
+
 	req := tracker_ws.QueryWorkItemsInBaseline{
 		Query:            query,
 		BaselineRevision: baselineRevision,
@@ -291,6 +329,10 @@ func (p *Polarion) QueryWorkItemsInBaseline(
 }
 
 func (p *Polarion) QueryRevisions(query string, fields []string, sort string) ([]*tracker_ws.Revision, error) {
+	if err := validateFields("revision", fields, revisionFieldNames, nil, false); err != nil {
+		return nil, err
+	}
+
 	req := tracker_ws.QueryRevisions{
 		Query:  query,
 		Fields: fields,
@@ -309,10 +351,14 @@ func (p *Polarion) QueryWorkItemsInBaselineBySQL(
 	baselineRevision, sqlQuery string,
 	fields []string,
 ) ([]*tracker_ws.WorkItem, error) {
+	if err := p.ValidateWorkItemFields(context.Background(), "", fields); err != nil {
+		return nil, err
+	}
+
 	sqlReq := tracker_ws.QueryWorkItemsInBaselineBySQL{
 		SqlQuery:         sqlQuery,
 		BaselineRevision: baselineRevision,
-		Fields:           []string{"id", "title", "status", "updated"},
+		Fields:           fields,
 	}
 
 	resp, err := p.TrackerWS.QueryWorkItemsInBaselineBySQL(&sqlReq)
@@ -335,3 +381,73 @@ func (p *Polarion) GetCustomField(wiURI *tracker_ws.SubterraURI, key string) (*t
 	}
 	return resp.GetCustomFieldReturn, nil
 }
+
+// wrapped by workItemFields when work item does not exist or is not accessible
+var errWorkItemNotFound = errors.New("not found")
+
+func (p *Polarion) workItemFields(ctx context.Context, uri *tracker_ws.SubterraURI, keys ...string) (*tracker_ws.WorkItem, error) {
+	req := tracker_ws.GetWorkItemByUriWithFields{
+		Uri:  uri,
+		Keys: keys,
+	}
+	resp, err := p.TrackerWS.GetWorkItemByUriWithFieldsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get work item %s: %v", model.URI(uri), err)
+	}
+	if resp.GetWorkItemByUriWithFieldsReturn == nil {
+		return nil, fmt.Errorf("work item %s %w", model.URI(uri), errWorkItemNotFound)
+	}
+	return resp.GetWorkItemByUriWithFieldsReturn, nil
+}
+
+// GetWorkItemModelById returns work item converted to model.WorkItem with its custom fields
+func (p *Polarion) GetWorkItemModelById(projectId, itemId string) (*model.WorkItem, error) {
+	req := tracker_ws.GetWorkItemById{
+		ProjectId:  projectId,
+		WorkitemId: itemId,
+	}
+	resp := &getWorkItemByIdResponse{}
+	if err := p.TrackerClient.CallContext(context.Background(), "''", &req, resp); err != nil {
+		return nil, fmt.Errorf("error getting work item %v", err)
+	}
+	return WorkItemModel(resp.GetWorkItemByIdReturn)
+}
+
+// QueryWorkItemModels is QueryWorkItems returning model.WorkItem values with custom fields
+func (p *Polarion) QueryWorkItemModels(
+	query, sortField string,
+	fields []string,
+) ([]*model.WorkItem, error) {
+	// same request as QueryWorkItems, sort is sent only with fields
+	ctx := context.Background()
+	if len(fields) > 0 {
+		if err := p.ValidateWorkItemFields(ctx, queryProjectID(query), fields); err != nil {
+			return nil, err
+		}
+		if sortField == "" {
+			return nil, fmt.Errorf(
+				"sortField should be specified if fields parameter is provided",
+			)
+		}
+	} else {
+		sortField = ""
+	}
+
+	items, err := p.queryWorkItems(ctx, query, sortField, fields)
+	if err != nil {
+		return nil, fmt.Errorf("error querying work items: %v", err)
+	}
+
+	result := make([]*model.WorkItem, 0, len(items))
+	for _, wi := range items {
+		if wi == nil {
+			continue
+		}
+		item, err := WorkItemModel(wi)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
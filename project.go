@@ -0,0 +1,59 @@
+package polarion_wsdl
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// project web service has no generated client, project has the same fields as in tracker types
+type getProjectRequest struct {
+	XMLName   xml.Name `xml:"http://ws.polarion.com/ProjectWebService-impl getProject"`
+	ProjectID string   `xml:"projectId"`
+}
+
+type getProjectResponseEnvelope struct {
+	Body struct {
+		Response struct {
+			Project *tracker_ws.Project `xml:"getProjectReturn"`
+		} `xml:"getProjectResponse"`
+	} `xml:"Body"`
+}
+
+// Project returns project by its ID
+func (p *Polarion) Project(ctx context.Context, projectID string) (*tracker_ws.Project, error) {
+	envelope, err := p.sessionEnvelope(&getProjectRequest{ProjectID: projectID})
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.postSOAP(ctx, p.projectEndpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project '%s': %v", projectID, err)
+	}
+	defer res.Body.Close()
+
+	resp := getProjectResponseEnvelope{}
+	if err := xml.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal project '%s': %v", projectID, err)
+	}
+	project := resp.Body.Response.Project
+	if project == nil || project.Unresolvable {
+		return nil, fmt.Errorf("project '%s' not found", projectID)
+	}
+	return project, nil
+}
+
+// WorkRecordsLockDate returns date up to which (inclusive) work records of project can not be changed,
+// zero time if work records are not locked
+func (p *Polarion) WorkRecordsLockDate(ctx context.Context, projectID string) (time.Time, error) {
+	project, err := p.Project(ctx, projectID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return model.FromXSDDate(project.LockWorkRecordsDate), nil
+}
@@ -0,0 +1,81 @@
+package polarion_wsdl
+
+// requests which do not go through generated soap clients,
+// used for streaming and for services without generated client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hooklift/gowsdl/soap"
+)
+
+// request envelope with session header, same as soap client would send
+func (p *Polarion) sessionEnvelope(request any) ([]byte, error) {
+	envelope := soap.SOAPEnvelope{
+		XmlNS:  soap.XmlNsSoapEnv,
+		Header: &soap.SOAPHeader{Headers: []interface{}{p.sessionHeader}},
+		Body:   soap.SOAPBody{Content: request},
+	}
+	envelopeBytes, err := xml.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request envelope %v", err)
+	}
+	return envelopeBytes, nil
+}
+
+// posts envelope to web service endpoint, response body has to be closed if error is nil.
+// Client timeout limits the whole exchange including streamed request and response body.
+func (p *Polarion) postSOAP(ctx context.Context, endpoint string, envelope io.Reader) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if p.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, envelope)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=\"utf-8\"")
+	req.Header.Set("SOAPAction", "''")
+
+	res, err := p.HttpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer cancel()
+		defer res.Body.Close()
+		return nil, soapFaultError(res)
+	}
+	res.Body = &cancelingBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// releases timeout context when response body is closed
+type cancelingBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func soapFaultError(res *http.Response) error {
+	fault := struct {
+		Body struct {
+			Fault *soap.SOAPFault
+		}
+	}{}
+	if err := xml.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&fault); err != nil || fault.Body.Fault == nil {
+		return fmt.Errorf("response status: %d", res.StatusCode)
+	}
+	return fmt.Errorf("%s: %s", fault.Body.Fault.Code, fault.Body.Fault.String)
+}
@@ -0,0 +1,313 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/session_ws"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// SuspectLink is link which needs review because linked work item changed
+type SuspectLink struct {
+	SourceURI   string
+	SourceID    string
+	SourceTitle string
+	TargetURI   string
+	TargetID    string
+	TargetTitle string
+	Role        string
+
+	// display name of role in project of source work item
+	RoleLabel string
+
+	// revision the link is pinned to, empty for HEAD
+	Revision string
+
+	// latest revision of target work item at the time of audit
+	TargetRevision string
+
+	// first revision of target work item after the link was last cleared
+	// (or created), the change which made link suspect, empty if not known
+	TriggerRevision string
+}
+
+type SuspectClearStatus string
+
+const (
+	SuspectCleared        SuspectClearStatus = "cleared"
+	SuspectWouldBeCleared SuspectClearStatus = "would be cleared"
+	SuspectSkipped        SuspectClearStatus = "skipped"
+	SuspectFailed         SuspectClearStatus = "failed"
+)
+
+type SuspectClearResult struct {
+	Link   SuspectLink
+	Status SuspectClearStatus
+
+	// why link was skipped
+	Reason string
+	Err    error
+}
+
+// SuspectLinks finds suspect links of work items matching query.
+// To find triggering revisions, history of each source work item with suspect links
+// is read back to the revision in which the links were last not suspect,
+// which takes one request per source revision and one per target work item.
+func (p *Polarion) SuspectLinks(ctx context.Context, query string) ([]SuspectLink, error) {
+	req := tracker_ws.QueryWorkItems{
+		Query:  query,
+		Sort:   "id",
+		Fields: []string{"id", "title", "linkedWorkItems"},
+	}
+	resp, err := p.TrackerWS.QueryWorkItemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work items '%s': %v", query, err)
+	}
+
+	var links []SuspectLink
+	targets := map[string]*model.WorkItem{}
+	revisions := map[string][]string{}
+	for _, wi := range resp.QueryWorkItemsReturn {
+		source := model.WorkItemFromWS(wi)
+		var suspect []model.Link
+		for _, link := range source.Links {
+			if link.Suspect {
+				suspect = append(suspect, link)
+			}
+		}
+		if len(suspect) == 0 {
+			continue
+		}
+
+		cleared, err := p.suspectSince(ctx, wi.Uri, suspect)
+		if err != nil {
+			return nil, err
+		}
+		labeler := p.EnumLabeler(uriProjectID(wi.Uri), "")
+		for i, link := range suspect {
+			target, ok := targets[link.URI]
+			if !ok {
+				if target, err = p.workItemSummary(ctx, link.URI); err != nil {
+					return nil, err
+				}
+				if revisions[link.URI], err = p.revisions(ctx, model.NewURI(link.URI)); err != nil {
+					return nil, err
+				}
+				targets[link.URI] = target
+			}
+
+			suspectLink := SuspectLink{
+				SourceURI:       source.URI,
+				SourceID:        source.ID,
+				SourceTitle:     source.Title,
+				TargetURI:       link.URI,
+				TargetID:        target.ID,
+				TargetTitle:     target.Title,
+				Role:            link.Role,
+				RoleLabel:       labeler.EnumLabel(ctx, linkRoleEnumID, link.Role),
+				Revision:        link.Revision,
+				TriggerRevision: firstRevisionAfter(revisions[link.URI], cleared[i]),
+			}
+			if n := len(revisions[link.URI]); n > 0 {
+				suspectLink.TargetRevision = revisions[link.URI][n-1]
+			}
+			links = append(links, suspectLink)
+		}
+	}
+	return links, nil
+}
+
+// revisions of work item from oldest to latest
+func (p *Polarion) revisions(ctx context.Context, uri *tracker_ws.SubterraURI) ([]string, error) {
+	resp, err := p.TrackerWS.GetRevisionsContext(ctx, &tracker_ws.GetRevisions{In0: uri})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revisions of %s: %v", model.URI(uri), err)
+	}
+	return resp.GetRevisionsReturn, nil
+}
+
+// latest revision of source work item in which each of links was not suspect
+// or did not exist yet, oldest source revision for links suspect since creation
+// of source work item
+func (p *Polarion) suspectSince(ctx context.Context, source *tracker_ws.SubterraURI, links []model.Link) ([]string, error) {
+	revisions, err := p.revisions(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	cleared := make([]string, len(links))
+	pending := len(links)
+	for i := len(revisions) - 1; i >= 0 && pending > 0; i-- {
+		req := tracker_ws.GetWorkItemByUriInRevisionWithFields{
+			Uri:      source,
+			Revision: revisions[i],
+			Keys:     []string{"linkedWorkItems"},
+		}
+		resp, err := p.TrackerWS.GetWorkItemByUriInRevisionWithFieldsContext(ctx, &req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links of work item %s in revision %s: %v", model.URI(source), revisions[i], err)
+		}
+		var old []model.Link
+		if resp.GetWorkItemByUriInRevisionWithFieldsReturn != nil {
+			old = model.WorkItemFromWS(resp.GetWorkItemByUriInRevisionWithFieldsReturn).Links
+		}
+
+		for j, link := range links {
+			if cleared[j] != "" || suspectIn(old, link) {
+				continue
+			}
+			cleared[j] = revisions[i]
+			pending--
+		}
+	}
+
+	if len(revisions) > 0 {
+		for j := range cleared {
+			if cleared[j] == "" {
+				cleared[j] = revisions[0]
+			}
+		}
+	}
+	return cleared, nil
+}
+
+// link is present in links and suspect
+func suspectIn(links []model.Link, link model.Link) bool {
+	for _, l := range links {
+		if l.URI == link.URI && l.Role == link.Role && l.Revision == link.Revision {
+			return l.Suspect
+		}
+	}
+	return false
+}
+
+// first of ordered revisions newer than revision, all revisions are newer than empty one
+func firstRevisionAfter(revisions []string, revision string) string {
+	for _, r := range revisions {
+		if revision == "" || revisionNewer(r, revision) {
+			return r
+		}
+	}
+	return ""
+}
+
+// repository revisions are numbers, other revisions are compared as strings
+func revisionNewer(a, b string) bool {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return x > y
+}
+
+// work item with ID and title only
+func (p *Polarion) workItemSummary(ctx context.Context, uri string) (*model.WorkItem, error) {
+	wi, err := p.workItemFields(ctx, model.NewURI(uri), "id", "title")
+	if errors.Is(err, errWorkItemNotFound) {
+		// deleted or inaccessible work item is reported without title
+		return &model.WorkItem{URI: uri}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return model.WorkItemFromWS(wi), nil
+}
+
+// ClearSuspects clears reviewed suspect links by re-adding them as not suspect.
+// Link is skipped if it is no longer suspect or target work item changed since the audit
+// (its latest revision differs from TargetRevision). With dryRun nothing is changed
+// and links which would be cleared are reported.
+func (p *Polarion) ClearSuspects(ctx context.Context, links []SuspectLink, dryRun bool) []SuspectClearResult {
+	results := make([]SuspectClearResult, 0, len(links))
+	for _, link := range links {
+		result := SuspectClearResult{Link: link}
+		if err := ctx.Err(); err != nil {
+			result.Status, result.Err = SuspectFailed, err
+			results = append(results, result)
+			continue
+		}
+
+		reason, err := p.suspectChanged(ctx, link)
+		switch {
+		case err != nil:
+			result.Status, result.Err = SuspectFailed, err
+		case reason != "":
+			result.Status, result.Reason = SuspectSkipped, reason
+		case dryRun:
+			result.Status = SuspectWouldBeCleared
+		default:
+			result.Status = SuspectCleared
+			if err := p.clearSuspect(ctx, link); err != nil {
+				result.Status, result.Err = SuspectFailed, err
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// reason why suspect link can not be cleared, empty if it is still as audited
+func (p *Polarion) suspectChanged(ctx context.Context, link SuspectLink) (string, error) {
+	wi, err := p.workItemFields(ctx, model.NewURI(link.SourceURI), "linkedWorkItems")
+	if errors.Is(err, errWorkItemNotFound) {
+		return "source work item not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	found := false
+	for _, l := range model.WorkItemFromWS(wi).Links {
+		if l.URI == link.TargetURI && l.Role == link.Role && l.Revision == link.Revision {
+			if !l.Suspect {
+				return "link is no longer suspect", nil
+			}
+			found = true
+		}
+	}
+	if !found {
+		return "link no longer exists", nil
+	}
+
+	if link.TargetRevision != "" {
+		revision, err := p.latestRevision(ctx, model.NewURI(link.TargetURI))
+		if err != nil {
+			return "", err
+		}
+		if revision != link.TargetRevision {
+			return fmt.Sprintf("target changed in revision %s after review of %s", revision, link.TargetRevision), nil
+		}
+	}
+	return "", nil
+}
+
+// Polarion has no operation to change suspect flag, so the link is replaced
+// in session transaction, which is rolled back if link can not be added back
+func (p *Polarion) clearSuspect(ctx context.Context, link SuspectLink) (err error) {
+	if _, err := p.SessionWS.BeginTransactionContext(ctx, &session_ws.BeginTransaction{}); err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	rollback := true
+	defer func() {
+		req := session_ws.EndTransaction{Rollback: rollback}
+		if _, endErr := p.SessionWS.EndTransactionContext(context.WithoutCancel(ctx), &req); endErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to end transaction (rollback: %v): %v", rollback, endErr))
+		}
+	}()
+
+	source := model.NewURI(link.SourceURI)
+	cleared := model.Link{URI: link.TargetURI, Role: link.Role, Revision: link.Revision}
+	if err := p.removeLink(ctx, source, cleared); err != nil {
+		return err
+	}
+	if err := p.addLink(ctx, source, cleared); err != nil {
+		return err
+	}
+	rollback = false
+	return nil
+}
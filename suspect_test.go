@@ -0,0 +1,51 @@
+package polarion_wsdl
+
+import (
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+)
+
+func TestFirstRevisionAfter(t *testing.T) {
+	revisions := []string{"98", "120", "1005"}
+	tests := []struct {
+		name     string
+		revision string
+		want     string
+	}{
+		{"empty revision", "", "98"},
+		{"before all", "50", "98"},
+		{"equal is not after", "98", "120"},
+		{"numeric order", "999", "1005"},
+		{"after all", "1005", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstRevisionAfter(revisions, tt.revision); got != tt.want {
+				t.Errorf("firstRevisionAfter(%q) = %q, want %q", tt.revision, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuspectIn(t *testing.T) {
+	link := model.Link{URI: "target", Role: "verifies"}
+	tests := []struct {
+		name  string
+		links []model.Link
+		want  bool
+	}{
+		{"missing", nil, false},
+		{"suspect", []model.Link{{URI: "target", Role: "verifies", Suspect: true}}, true},
+		{"cleared", []model.Link{{URI: "target", Role: "verifies"}}, false},
+		{"other role", []model.Link{{URI: "target", Role: "relates_to", Suspect: true}}, false},
+		{"pinned revision", []model.Link{{URI: "target", Role: "verifies", Revision: "12", Suspect: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suspectIn(tt.links, link); got != tt.want {
+				t.Errorf("suspectIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
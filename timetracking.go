@@ -0,0 +1,407 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// enum of work record types
+const workRecordTypeEnumID = "work-record-type"
+
+// ErrWorkRecordsLocked is returned when work record is dated on or before lock date of its project
+var ErrWorkRecordsLocked = errors.New("work records are locked")
+
+// WorkRecord is time logged on work item
+type WorkRecord struct {
+	URI         string
+	ID          string
+	WorkItemURI string
+	WorkItemID  string
+	ProjectID   string
+	UserID      string
+	Date        time.Time
+	Type        string
+	Comment     string
+	Spent       Duration
+
+	// record is dated on or before lock date of its project and can not be changed
+	Locked bool
+}
+
+type LogTimeOptions struct {
+	// work record type, e.g. "development", must be option of project work record types
+	Type    string
+	Comment string
+}
+
+// LogTime creates work record of user on work item.
+// ErrWorkRecordsLocked is returned if date is not after lock date of work item project.
+func (p *Polarion) LogTime(
+	ctx context.Context,
+	workItemURI *tracker_ws.SubterraURI,
+	userID string,
+	date time.Time,
+	spent Duration,
+	opts *LogTimeOptions,
+) error {
+	if workItemURI == nil || userID == "" || date.IsZero() || spent.IsZero() {
+		return fmt.Errorf("work item URI, user, date and time spent are required for work record")
+	}
+	if opts == nil {
+		opts = &LogTimeOptions{}
+	}
+
+	wi, err := p.workItemFields(ctx, workItemURI, "project")
+	if err != nil {
+		return err
+	}
+	projectID := projectIDOf(wi.Project)
+	if err := p.checkWorkRecordsLock(ctx, projectID, date); err != nil {
+		return err
+	}
+
+	user := &tracker_ws.User{Uri: userURI(userID)}
+	if opts.Type == "" && opts.Comment == "" {
+		req := tracker_ws.CreateWorkRecord{
+			WorkitemURI: workItemURI,
+			User:        user,
+			Date:        model.ToXSDDate(date),
+			TimeSpent:   spent.ToWS(),
+		}
+		if _, err := p.TrackerWS.CreateWorkRecordContext(ctx, &req); err != nil {
+			return fmt.Errorf("failed to log time on work item %s: %v", model.URI(workItemURI), err)
+		}
+		return nil
+	}
+
+	if opts.Type != "" {
+		option, err := p.EnumOption(ctx, projectID, "", workRecordTypeEnumID, opts.Type)
+		if err != nil {
+			return err
+		}
+		if option == nil {
+			return fmt.Errorf("unknown work record type '%s' in project '%s'", opts.Type, projectID)
+		}
+	}
+	req := tracker_ws.CreateWorkRecordWithTypeAndComment{
+		WorkitemURI: workItemURI,
+		User:        user,
+		Date:        model.ToXSDDate(date),
+		Type_:       model.NewEnumID(opts.Type),
+		TimeSpent:   spent.ToWS(),
+		Comment:     opts.Comment,
+	}
+	if _, err := p.TrackerWS.CreateWorkRecordWithTypeAndCommentContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to log time on work item %s: %v", model.URI(workItemURI), err)
+	}
+	return nil
+}
+
+// DeleteWorkRecord deletes work record of work item unless it is locked
+func (p *Polarion) DeleteWorkRecord(ctx context.Context, workItemURI, workRecordURI *tracker_ws.SubterraURI) error {
+	records, err := p.WorkRecords(ctx, workItemURI)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(records, func(r WorkRecord) bool { return r.URI == model.URI(workRecordURI) })
+	if i < 0 {
+		return fmt.Errorf("work record %s not found in work item %s", model.URI(workRecordURI), model.URI(workItemURI))
+	}
+	if records[i].Locked {
+		return fmt.Errorf("%w: can not delete work record %s dated %s",
+			ErrWorkRecordsLocked, records[i].URI, records[i].Date.Format(time.DateOnly))
+	}
+
+	req := tracker_ws.DeleteWorkRecord{
+		WorkitemURI:   workItemURI,
+		WorkRecordURI: workRecordURI,
+	}
+	if _, err := p.TrackerWS.DeleteWorkRecordContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to delete work record %s: %v", model.URI(workRecordURI), err)
+	}
+	return nil
+}
+
+// WorkRecords returns work records of work item, oldest first
+func (p *Polarion) WorkRecords(ctx context.Context, workItemURI *tracker_ws.SubterraURI) ([]WorkRecord, error) {
+	wi, err := p.workItemFields(ctx, workItemURI, "id", "project", "workRecords")
+	if err != nil {
+		return nil, err
+	}
+	lockDate, err := p.WorkRecordsLockDate(ctx, projectIDOf(wi.Project))
+	if err != nil {
+		return nil, err
+	}
+	records, err := workRecordsFromWS(wi, lockDate)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(records, func(a, b WorkRecord) int {
+		return a.Date.Compare(b.Date)
+	})
+	return records, nil
+}
+
+func (p *Polarion) checkWorkRecordsLock(ctx context.Context, projectID string, date time.Time) error {
+	lockDate, err := p.WorkRecordsLockDate(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if isLocked(date, lockDate) {
+		return fmt.Errorf("%w: project '%s' is locked until %s, can not log time on %s",
+			ErrWorkRecordsLocked, projectID, lockDate.Format(time.DateOnly), date.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// compares calendar dates only, zero lock date locks nothing
+func isLocked(date, lockDate time.Time) bool {
+	return !lockDate.IsZero() && !civilDate(date).After(civilDate(lockDate))
+}
+
+// date without time in UTC, so dates parsed in different time zones compare by calendar day
+func civilDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// user URI as expected in work records
+func userURI(userID string) *tracker_ws.SubterraURI {
+	uri := tracker_ws.SubterraURI(fmt.Sprintf("subterra:data-service:objects:/default/${User}%s", userID))
+	return &uri
+}
+
+// user ID from user reference, unresolved users have only URI
+func userIDOf(user *tracker_ws.User) string {
+	if user == nil {
+		return ""
+	}
+	if user.Id != "" || user.Uri == nil {
+		return user.Id
+	}
+	_, id, _ := strings.Cut(string(*user.Uri), "${User}")
+	return id
+}
+
+func workRecordsFromWS(wi *tracker_ws.WorkItem, lockDate time.Time) ([]WorkRecord, error) {
+	if wi.WorkRecords == nil {
+		return nil, nil
+	}
+
+	var records []WorkRecord
+	for _, r := range wi.WorkRecords.WorkRecord {
+		if r == nil {
+			continue
+		}
+		spent, err := DurationFromWS(r.TimeSpent)
+		if err != nil {
+			return nil, fmt.Errorf("work record %s of work item %s: %v", model.URI(r.Uri), wi.Id, err)
+		}
+		date := model.FromXSDDate(r.Date)
+		records = append(records, WorkRecord{
+			URI:         model.URI(r.Uri),
+			ID:          r.Id,
+			WorkItemURI: model.URI(wi.Uri),
+			WorkItemID:  wi.Id,
+			ProjectID:   projectIDOf(wi.Project),
+			UserID:      userIDOf(r.User),
+			Date:        date,
+			Type:        model.EnumID(r.Type_),
+			Comment:     r.Comment,
+			Spent:       spent,
+			Locked:      isLocked(date, lockDate),
+		})
+	}
+	return records, nil
+}
+
+type TimesheetGroup string
+
+const (
+	TimesheetByUser    TimesheetGroup = "user"
+	TimesheetByDay     TimesheetGroup = "day"
+	TimesheetByType    TimesheetGroup = "type"
+	TimesheetByProject TimesheetGroup = "project"
+)
+
+type TimesheetOptions struct {
+	// query selecting work items whose work records are aggregated
+	Query string
+
+	// inclusive range of work record dates, zero From or To leaves range open
+	From time.Time
+	To   time.Time
+
+	// work records are summed per combination of group values, single row if empty
+	GroupBy []TimesheetGroup
+}
+
+type TimesheetRow struct {
+	// values of groups in order of GroupBy, days are formatted as 2006-01-02
+	Keys []string
+
+	// display values of groups, work record types are labeled
+	Labels []string
+
+	// normalized to full days of server day length
+	Spent Duration
+	Hours float64
+
+	// hours of locked records, which can not be changed anymore
+	LockedHours float64
+	Records     []WorkRecord
+}
+
+type Timesheet struct {
+	GroupBy   []TimesheetGroup
+	DayLength time.Duration
+
+	// lock dates of projects with locked work records
+	LockDates map[string]time.Time
+
+	Rows       []TimesheetRow
+	Total      Duration
+	TotalHours float64
+}
+
+// BuildTimesheet sums work records of work items matching query within date range,
+// durations are converted to hours with server day length
+func (p *Polarion) BuildTimesheet(ctx context.Context, opts TimesheetOptions) (*Timesheet, error) {
+	if opts.Query == "" {
+		return nil, fmt.Errorf("query is required for timesheet")
+	}
+	for _, group := range opts.GroupBy {
+		switch group {
+		case TimesheetByUser, TimesheetByDay, TimesheetByType, TimesheetByProject:
+		default:
+			return nil, fmt.Errorf("unknown timesheet group '%s'", group)
+		}
+	}
+
+	dayLength, err := p.DayLength(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := tracker_ws.QueryWorkItems{
+		Query:  opts.Query,
+		Sort:   "id",
+		Fields: []string{"id", "project", "workRecords"},
+	}
+	resp, err := p.TrackerWS.QueryWorkItemsContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work items '%s': %v", opts.Query, err)
+	}
+
+	timesheet := &Timesheet{
+		GroupBy:   opts.GroupBy,
+		DayLength: dayLength,
+		LockDates: map[string]time.Time{},
+	}
+	lockDates := map[string]time.Time{}
+	rows := map[string]*TimesheetRow{}
+	for _, wi := range resp.QueryWorkItemsReturn {
+		if wi == nil || wi.WorkRecords == nil {
+			continue
+		}
+
+		projectID := projectIDOf(wi.Project)
+		lockDate, ok := lockDates[projectID]
+		if !ok {
+			if lockDate, err = p.WorkRecordsLockDate(ctx, projectID); err != nil {
+				return nil, err
+			}
+			lockDates[projectID] = lockDate
+			if !lockDate.IsZero() {
+				timesheet.LockDates[projectID] = lockDate
+			}
+		}
+
+		records, err := workRecordsFromWS(wi, lockDate)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if !inDateRange(record.Date, opts.From, opts.To) {
+				continue
+			}
+
+			keys := timesheetKeys(record, opts.GroupBy)
+			rowKey := strings.Join(keys, "\x00")
+			row, ok := rows[rowKey]
+			if !ok {
+				row = &TimesheetRow{Keys: keys, Labels: p.timesheetLabels(ctx, record, opts.GroupBy, keys)}
+				rows[rowKey] = row
+			}
+			hours := record.Spent.Hours(dayLength)
+			row.Spent = row.Spent.Add(record.Spent)
+			row.Hours += hours
+			if record.Locked {
+				row.LockedHours += hours
+			}
+			row.Records = append(row.Records, record)
+			timesheet.Total = timesheet.Total.Add(record.Spent)
+		}
+	}
+
+	for _, row := range rows {
+		row.Spent = row.Spent.Normalize(dayLength)
+		slices.SortStableFunc(row.Records, func(a, b WorkRecord) int {
+			return a.Date.Compare(b.Date)
+		})
+		timesheet.Rows = append(timesheet.Rows, *row)
+	}
+	slices.SortFunc(timesheet.Rows, func(a, b TimesheetRow) int {
+		return slices.Compare(a.Keys, b.Keys)
+	})
+	timesheet.TotalHours = timesheet.Total.Hours(dayLength)
+	timesheet.Total = timesheet.Total.Normalize(dayLength)
+	return timesheet, nil
+}
+
+func inDateRange(date, from, to time.Time) bool {
+	day := civilDate(date)
+	if !from.IsZero() && day.Before(civilDate(from)) {
+		return false
+	}
+	if !to.IsZero() && day.After(civilDate(to)) {
+		return false
+	}
+	return true
+}
+
+// work record types are labeled with enum of record project,
+// rows grouped by type across projects use labels of first record
+func (p *Polarion) timesheetLabels(ctx context.Context, record WorkRecord, groups []TimesheetGroup, keys []string) []string {
+	labels := slices.Clone(keys)
+	for i, group := range groups {
+		if group == TimesheetByType && record.Type != "" {
+			labels[i] = p.EnumLabeler(record.ProjectID, "").EnumLabel(ctx, workRecordTypeEnumID, record.Type)
+		}
+	}
+	return labels
+}
+
+func timesheetKeys(record WorkRecord, groups []TimesheetGroup) []string {
+	keys := make([]string, 0, len(groups))
+	for _, group := range groups {
+		switch group {
+		case TimesheetByUser:
+			keys = append(keys, record.UserID)
+		case TimesheetByDay:
+			keys = append(keys, record.Date.Format(time.DateOnly))
+		case TimesheetByType:
+			keys = append(keys, record.Type)
+		case TimesheetByProject:
+			keys = append(keys, record.ProjectID)
+		}
+	}
+	return keys
+}
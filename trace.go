@@ -0,0 +1,330 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+const defaultTraceParallelism = 4
+
+// fields fetched for every traced work item
+var traceFields = []string{"id", "title", "type", "status", "project", "linkedWorkItems"}
+
+type TraceOptions struct {
+	// link roles to follow, all roles if empty
+	Roles []string
+
+	// directions to follow, both if empty
+	Directions []LinkDirection
+
+	// work item types included in graph, other work items are neither included nor followed,
+	// roots are always included
+	Types []string
+
+	// maximal distance from roots, unlimited if 0
+	MaxDepth int
+
+	// additional fields fetched for work items
+	Fields []string
+
+	// number of work items fetched at once, 4 by default
+	Parallelism int
+}
+
+// TraceNode is traced work item, work item which does not exist or is not accessible
+// has URI only and is kept regardless of TraceOptions.Types
+type TraceNode struct {
+	URI   string `json:"uri"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+
+	// display name of type
+	TypeLabel string `json:"typeLabel,omitempty"`
+
+	// distance from nearest root
+	Depth int             `json:"depth"`
+	Item  *model.WorkItem `json:"-"`
+}
+
+// TraceEdge is link between traced work items, always from linking work item to linked one
+type TraceEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Role string `json:"role"`
+
+	// display name of role in project of linking work item
+	RoleLabel string `json:"roleLabel,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Suspect   bool   `json:"suspect,omitempty"`
+}
+
+type TraceGraph struct {
+	Roots []string     `json:"roots"`
+	Nodes []*TraceNode `json:"nodes"`
+	Edges []TraceEdge  `json:"edges"`
+
+	// cycles formed by edges, each as URIs of work items in link order
+	Cycles [][]string `json:"cycles,omitempty"`
+}
+
+// fetched work item with its outgoing and back links
+type traceFetch struct {
+	item  *model.WorkItem
+	links []WorkItemLink
+
+	// work item does not exist or is not accessible, item has URI only
+	missing bool
+}
+
+// Trace traverses links of work items breadth-first starting from roots.
+// Each level of graph is fetched in parallel.
+func (p *Polarion) Trace(
+	ctx context.Context,
+	roots []*tracker_ws.SubterraURI,
+	opts TraceOptions,
+) (*TraceGraph, error) {
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultTraceParallelism
+	}
+	fields := append(slices.Clone(traceFields), opts.Fields...)
+	if err := p.ValidateWorkItemFields(ctx, "", fields); err != nil {
+		return nil, err
+	}
+
+	graph := &TraceGraph{}
+	nodes := map[string]*TraceNode{}
+	edges := map[TraceEdge]struct{}{}
+	// work items of other types, kept to not fetch them again
+	excluded := map[string]struct{}{}
+
+	var level []string
+	for _, root := range roots {
+		uri := model.URI(root)
+		if _, ok := nodes[uri]; ok || uri == "" {
+			continue
+		}
+		graph.Roots = append(graph.Roots, uri)
+		nodes[uri] = &TraceNode{URI: uri}
+		level = append(level, uri)
+	}
+
+	for depth := 0; len(level) > 0; depth++ {
+		fetched, err := p.traceLevel(ctx, level, fields, parallelism)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []string
+		for i, uri := range level {
+			item := fetched[i].item
+			if depth > 0 && len(opts.Types) > 0 && !fetched[i].missing && !slices.Contains(opts.Types, item.Type) {
+				delete(nodes, uri)
+				excluded[uri] = struct{}{}
+				continue
+			}
+
+			node := nodes[uri]
+			node.ID, node.Title, node.Type, node.Depth, node.Item = item.ID, item.Title, item.Type, depth, item
+			if item.Type != "" {
+				node.TypeLabel = p.EnumLabeler(uriProjectID(model.NewURI(uri)), "").EnumLabel(ctx, "type", item.Type)
+			}
+			graph.Nodes = append(graph.Nodes, node)
+			if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+				continue
+			}
+
+			for _, link := range fetched[i].links {
+				if !traceFollows(opts, link) {
+					continue
+				}
+				edge := TraceEdge{From: uri, To: link.URI, Role: link.Role, Revision: link.Revision, Suspect: link.Suspect}
+				if link.Direction == LinkBack {
+					edge.From, edge.To = link.URI, uri
+				}
+				edge.RoleLabel = p.EnumLabeler(uriProjectID(model.NewURI(edge.From)), "").EnumLabel(ctx, linkRoleEnumID, edge.Role)
+				edges[edge] = struct{}{}
+
+				_, isExcluded := excluded[link.URI]
+				if _, ok := nodes[link.URI]; !ok && !isExcluded {
+					nodes[link.URI] = &TraceNode{URI: link.URI}
+					next = append(next, link.URI)
+				}
+			}
+		}
+		level = next
+	}
+
+	// edges to filtered out work items are dropped
+	for edge := range edges {
+		_, from := nodes[edge.From]
+		_, to := nodes[edge.To]
+		if from && to {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+	slices.SortFunc(graph.Edges, func(a, b TraceEdge) int {
+		return strings.Compare(a.From+"\x00"+a.To+"\x00"+a.Role, b.From+"\x00"+b.To+"\x00"+b.Role)
+	})
+	graph.Cycles = findCycles(graph.Nodes, graph.Edges)
+
+	return graph, nil
+}
+
+func traceFollows(opts TraceOptions, link WorkItemLink) bool {
+	if len(opts.Roles) > 0 && !slices.Contains(opts.Roles, link.Role) {
+		return false
+	}
+	return len(opts.Directions) == 0 || slices.Contains(opts.Directions, link.Direction)
+}
+
+// fetches work items with their outgoing and back links, at most parallelism requests at once
+func (p *Polarion) traceLevel(
+	ctx context.Context,
+	uris []string,
+	fields []string,
+	parallelism int,
+) ([]traceFetch, error) {
+	results := make([]traceFetch, len(uris))
+	errs := make([]error, len(uris))
+	limit := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for i, uri := range uris {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, uri *tracker_ws.SubterraURI) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			results[i], errs[i] = p.traceFetch(ctx, uri, fields)
+		}(i, model.NewURI(uri))
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+func (p *Polarion) traceFetch(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	fields []string,
+) (traceFetch, error) {
+	if err := ctx.Err(); err != nil {
+		return traceFetch{}, err
+	}
+
+	wi, err := p.workItemFields(ctx, uri, fields...)
+	if errors.Is(err, errWorkItemNotFound) {
+		// deleted or inaccessible work item is reported without links
+		return traceFetch{item: &model.WorkItem{URI: model.URI(uri)}, missing: true}, nil
+	}
+	if err != nil {
+		return traceFetch{}, err
+	}
+	item := model.WorkItemFromWS(wi)
+
+	back, err := p.TrackerWS.GetBackLinkedWorkitemsContext(ctx, &tracker_ws.GetBackLinkedWorkitems{WorkitemURI: uri})
+	if err != nil {
+		return traceFetch{}, fmt.Errorf("failed to get back links of work item %s: %v", model.URI(uri), err)
+	}
+
+	var links []WorkItemLink
+	for _, link := range item.Links {
+		links = append(links, WorkItemLink{Direction: LinkOutgoing, URI: link.URI, Role: link.Role, Revision: link.Revision, Suspect: link.Suspect})
+	}
+	for _, l := range back.GetBackLinkedWorkitemsReturn {
+		if l != nil {
+			link := model.LinkFromWS(l)
+			links = append(links, WorkItemLink{Direction: LinkBack, URI: link.URI, Role: link.Role, Revision: link.Revision, Suspect: link.Suspect})
+		}
+	}
+	return traceFetch{item: item, links: links}, nil
+}
+
+// finds cycles with depth-first search, each cycle is reported once from the node where it was entered
+func findCycles(nodes []*TraceNode, edges []TraceEdge) [][]string {
+	successors := map[string][]string{}
+	for _, edge := range edges {
+		successors[edge.From] = append(successors[edge.From], edge.To)
+	}
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	var cycles [][]string
+
+	var visit func(uri string)
+	visit = func(uri string) {
+		state[uri] = inProgress
+		stack = append(stack, uri)
+		for _, next := range successors[uri] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case inProgress:
+				start := slices.Index(stack, next)
+				cycles = append(cycles, slices.Clone(stack[start:]))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[uri] = done
+	}
+
+	for _, node := range nodes {
+		if state[node.URI] == unvisited {
+			visit(node.URI)
+		}
+	}
+	return cycles
+}
+
+func (g *TraceGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// DOT renders graph in Graphviz format, roots are bold and suspect links dashed
+func (g *TraceGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph trace {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range g.Nodes {
+		style := ""
+		if node.Depth == 0 {
+			style = ", style=bold"
+		}
+		label := node.ID
+		if node.Title != "" {
+			label += "\n" + node.Title
+		}
+		fmt.Fprintf(&b, "  %s [label=%s%s];\n", strconv.Quote(node.URI), strconv.Quote(label), style)
+	}
+	for _, edge := range g.Edges {
+		style := ""
+		if edge.Suspect {
+			style = ", style=dashed, color=red"
+		}
+		label := edge.RoleLabel
+		if label == "" {
+			label = edge.Role
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), strconv.Quote(label), style)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package polarion_wsdl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTraceGraphDOTLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		edge TraceEdge
+		want string
+	}{
+		{
+			"role label",
+			TraceEdge{From: "a", To: "b", Role: "verifies", RoleLabel: "Verifies"},
+			`"a" -> "b" [label="Verifies"];`,
+		},
+		{
+			"role ID without label",
+			TraceEdge{From: "a", To: "b", Role: "verifies"},
+			`"a" -> "b" [label="verifies"];`,
+		},
+		{
+			"suspect",
+			TraceEdge{From: "a", To: "b", Role: "verifies", RoleLabel: "Verifies", Suspect: true},
+			`"a" -> "b" [label="Verifies", style=dashed, color=red];`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := &TraceGraph{Edges: []TraceEdge{tt.edge}}
+			if got := graph.DOT(); !strings.Contains(got, tt.want) {
+				t.Errorf("DOT() = %q, want line %q", got, tt.want)
+			}
+		})
+	}
+}
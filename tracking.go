@@ -0,0 +1,447 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// fields which tracked work item updates with UpdateWorkItem (or SetFieldsNull),
+// other collections have dedicated operations (assignees and links are supported)
+var trackedWorkItemFields = []string{
+	"title",
+	"type",
+	"status",
+	"resolution",
+	"severity",
+	"priority",
+	"description",
+	"dueDate",
+	"plannedStart",
+	"plannedEnd",
+	"initialEstimate",
+	"remainingEstimate",
+	"timeSpent",
+	"location",
+	"timePoint",
+}
+
+// fields which can not be changed through tracked work item
+var untrackedWorkItemFields = []string{
+	"approvals",
+	"attachments",
+	"categories",
+	"comments",
+	"externallyLinkedWorkItems",
+	"hyperlinks",
+	"linkedOslcResources",
+	"linkedRevisions",
+	"plannedInURIs",
+	"planningConstraints",
+	"workRecords",
+}
+
+// TrackedWorkItem is fetched work item which remembers its original state,
+// so only fields changed in Item and Custom are written back by Save.
+type TrackedWorkItem struct {
+	Item *model.WorkItem
+
+	// decoded custom field values, see DecodeCustomValue, values of date fields are Date.
+	// Values which can not be decoded are kept as *CustomValue and written back unchanged
+	Custom map[string]any
+
+	polarion *Polarion
+
+	// marshalled work item as fetched (or last saved), used as deep copy
+	snapshot []byte
+}
+
+// WorkItemChanges lists differences of tracked work item from its snapshot
+type WorkItemChanges struct {
+	Fields           FieldMask
+	Custom           map[string]any
+	AddedAssignees   []string
+	RemovedAssignees []string
+	AddedLinks       []model.Link
+	RemovedLinks     []model.Link
+}
+
+func (c *WorkItemChanges) IsEmpty() bool {
+	return len(c.Fields) == 0 && len(c.Custom) == 0 &&
+		len(c.AddedAssignees) == 0 && len(c.RemovedAssignees) == 0 &&
+		len(c.AddedLinks) == 0 && len(c.RemovedLinks) == 0
+}
+
+// TrackWorkItem fetches work item and starts tracking its changes,
+// all fields are fetched if fields list is empty
+func (p *Polarion) TrackWorkItem(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	fields []string,
+) (*TrackedWorkItem, error) {
+	if err := p.ValidateWorkItemFields(ctx, uriProjectID(uri), fields); err != nil {
+		return nil, err
+	}
+
+	wi, err := p.getWorkItem(ctx, uri, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get work item %s: %v", model.URI(uri), err)
+	}
+	if wi == nil || wi.Unresolvable {
+		return nil, fmt.Errorf("work item %s not found", model.URI(uri))
+	}
+	return p.Track(wi)
+}
+
+// Track starts tracking changes of already fetched work item
+func (p *Polarion) Track(wi *WorkItem) (*TrackedWorkItem, error) {
+	tracked := &TrackedWorkItem{polarion: p}
+	if err := tracked.reset(wi); err != nil {
+		return nil, err
+	}
+	if tracked.Item.URI == "" {
+		return nil, fmt.Errorf("tracked work item must have URI")
+	}
+	return tracked, nil
+}
+
+func (t *TrackedWorkItem) reset(wi *WorkItem) error {
+	snapshot, err := xml.Marshal(wi)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot work item: %v", err)
+	}
+
+	// item and its snapshot must not share pointers
+	t.snapshot = snapshot
+	item, fields, err := t.original()
+	if err != nil {
+		return err
+	}
+	t.Item, t.Custom = item, trackedCustomFields(fields)
+	return nil
+}
+
+func (t *TrackedWorkItem) original() (*model.WorkItem, *ArrayOfCustom, error) {
+	wi := &WorkItem{}
+	if err := xml.Unmarshal(t.snapshot, wi); err != nil {
+		return nil, nil, fmt.Errorf("failed to restore work item snapshot: %v", err)
+	}
+	return model.WorkItemFromWS(&wi.WorkItem), wi.CustomFields, nil
+}
+
+// decodes custom fields like DecodeCustomFields, but keeps raw value
+// of fields with unsupported type instead of failing and dates as Date,
+// so they are compared by day and written back as dates
+func trackedCustomFields(fields *ArrayOfCustom) map[string]any {
+	values := map[string]any{}
+	if fields == nil {
+		return values
+	}
+
+	for _, field := range fields.Custom {
+		if field == nil {
+			continue
+		}
+		value, err := DecodeCustomValue(field.Value)
+		if err != nil {
+			values[field.Key] = field.Value
+			continue
+		}
+		if field.Value != nil {
+			value = customValueAs(value, field.Value.XSIType)
+		}
+		values[field.Key] = value
+	}
+	return values
+}
+
+// Changes compares tracked work item with its snapshot
+func (t *TrackedWorkItem) Changes() (*WorkItemChanges, error) {
+	original, originalFields, err := t.original()
+	if err != nil {
+		return nil, err
+	}
+	originalCustom := trackedCustomFields(originalFields)
+
+	changes := &WorkItemChanges{Fields: FieldMask{}, Custom: map[string]any{}}
+
+	for _, name := range untrackedWorkItemFields {
+		if !sameValue(modelField(original, name), modelField(t.Item, name)) {
+			return nil, fmt.Errorf("changes of work item field '%s' are not tracked, use dedicated API", name)
+		}
+	}
+
+	for _, name := range trackedWorkItemFields {
+		before, after := modelField(original, name), modelField(t.Item, name)
+		if !sameValue(before, after) {
+			changes.Fields[name] = after.Interface()
+		}
+	}
+
+	for key, value := range t.Custom {
+		// time.Time set to date field is a date, string set to enum field is an option
+		if raw, _ := customValue(originalFields, key); raw != nil {
+			value = customValueAs(value, raw.XSIType)
+		}
+		if before, ok := originalCustom[key]; !ok || !sameValue(reflect.ValueOf(before), reflect.ValueOf(value)) {
+			changes.Custom[key] = value
+		}
+	}
+	for key := range originalCustom {
+		if _, ok := t.Custom[key]; !ok {
+			changes.Custom[key] = nil
+		}
+	}
+
+	changes.AddedAssignees, changes.RemovedAssignees = diffStrings(
+		userIDs(original.Assignees), userIDs(t.Item.Assignees),
+	)
+	changes.AddedLinks, changes.RemovedLinks = diffLinks(original.Links, t.Item.Links)
+
+	return changes, nil
+}
+
+// Save applies changes with the smallest set of requests and refreshes snapshot.
+// Nothing is sent if work item was not changed.
+func (t *TrackedWorkItem) Save(ctx context.Context) (*WriteResult, error) {
+	changes, err := t.Changes()
+	if err != nil {
+		return nil, err
+	}
+
+	uri := model.NewURI(t.Item.URI)
+	if changes.IsEmpty() {
+		return &WriteResult{URI: uri, Updated: t.Item.Updated}, nil
+	}
+
+	if err := t.polarion.applyChanges(ctx, uri, changes); err != nil {
+		return nil, err
+	}
+
+	result, err := t.polarion.writeResult(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	// current state becomes new snapshot, changed custom values
+	// keep types they were written as
+	for key, value := range changes.Custom {
+		if value != nil {
+			t.Custom[key] = value
+		}
+	}
+	wi := &WorkItem{WorkItem: *t.Item.ToWS()}
+	wi.Updated = model.ToXSDDateTime(result.Updated)
+	if wi.CustomFields, err = encodeCustomFields(t.Custom); err != nil {
+		return nil, err
+	}
+	if err := t.reset(wi); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (p *Polarion) applyChanges(ctx context.Context, uri *tracker_ws.SubterraURI, changes *WorkItemChanges) error {
+	if len(changes.Fields) > 0 {
+		content, nullFields, err := p.contentFromMask(ctx, uri, changes.Fields)
+		if err != nil {
+			return err
+		}
+		if content != nil {
+			if err := p.updateWorkItem(ctx, uri, content); err != nil {
+				return err
+			}
+		}
+		if err := p.setFieldsNull(ctx, uri, nullFields); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range sortedKeys(changes.Custom) {
+		encoded, err := EncodeCustomValue(changes.Custom[key])
+		if err != nil {
+			return fmt.Errorf("failed to encode custom field '%s': %v", key, err)
+		}
+		if err := p.setCustomField(ctx, uri, key, encoded); err != nil {
+			return fmt.Errorf("failed to set custom field '%s' of work item %s: %v", key, *uri, err)
+		}
+	}
+
+	for _, id := range changes.RemovedAssignees {
+		req := tracker_ws.RemoveAssignee{WorkitemURI: uri, AssigneeId: id}
+		if _, err := p.TrackerWS.RemoveAssigneeContext(ctx, &req); err != nil {
+			return fmt.Errorf("failed to remove assignee '%s' from work item %s: %v", id, *uri, err)
+		}
+	}
+	for _, id := range changes.AddedAssignees {
+		req := tracker_ws.AddAssignee{WorkitemURI: uri, AssigneeId: id}
+		if _, err := p.TrackerWS.AddAssigneeContext(ctx, &req); err != nil {
+			return fmt.Errorf("failed to add assignee '%s' to work item %s: %v", id, *uri, err)
+		}
+	}
+
+	for _, link := range changes.RemovedLinks {
+		if err := p.removeLink(ctx, uri, link); err != nil {
+			return err
+		}
+	}
+	for _, link := range changes.AddedLinks {
+		if err := p.addLink(ctx, uri, link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Polarion) addLink(ctx context.Context, uri *tracker_ws.SubterraURI, link model.Link) error {
+	var err error
+	if link.Revision != "" || link.Suspect {
+		req := tracker_ws.AddLinkedItemWithRev{
+			In0: uri,
+			In1: model.NewURI(link.URI),
+			In2: model.NewEnumID(link.Role),
+			In3: link.Revision,
+			In4: link.Suspect,
+		}
+		_, err = p.TrackerWS.AddLinkedItemWithRevContext(ctx, &req)
+	} else {
+		req := tracker_ws.AddLinkedItem{
+			WorkitemURI:       uri,
+			LinkedWorkitemURI: model.NewURI(link.URI),
+			Role:              model.NewEnumID(link.Role),
+		}
+		_, err = p.TrackerWS.AddLinkedItemContext(ctx, &req)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to link work item %s to %s as '%s': %v", *uri, link.URI, link.Role, err)
+	}
+	return nil
+}
+
+func (p *Polarion) removeLink(ctx context.Context, uri *tracker_ws.SubterraURI, link model.Link) error {
+	req := tracker_ws.RemoveLinkedItem{
+		WorkitemURI:   uri,
+		LinkedItemURI: model.NewURI(link.URI),
+		Role:          model.NewEnumID(link.Role),
+	}
+	if _, err := p.TrackerWS.RemoveLinkedItemContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to unlink work item %s from %s as '%s': %v", *uri, link.URI, link.Role, err)
+	}
+	return nil
+}
+
+func encodeCustomFields(values map[string]any) (*ArrayOfCustom, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	fields := &ArrayOfCustom{}
+	for _, key := range sortedKeys(values) {
+		encoded, err := EncodeCustomValue(values[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode custom field '%s': %v", key, err)
+		}
+		fields.Custom = append(fields.Custom, &Custom{Key: key, Value: encoded})
+	}
+	return fields, nil
+}
+
+// compares values, times by instant, dates by day and pointers by pointed values
+func sameValue(a, b reflect.Value) bool {
+	for a.IsValid() && (a.Kind() == reflect.Pointer || a.Kind() == reflect.Interface) && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.IsValid() && (b.Kind() == reflect.Pointer || b.Kind() == reflect.Interface) && !b.IsNil() {
+		b = b.Elem()
+	}
+
+	if !a.IsValid() || !b.IsValid() {
+		return (!a.IsValid() || a.IsZero()) && (!b.IsValid() || b.IsZero())
+	}
+	if a.Type() == dateType && b.Type() == dateType {
+		return civilDate(a.Interface().(Date).Time).Equal(civilDate(b.Interface().(Date).Time))
+	}
+	if a.Type() == timeType && b.Type() == timeType {
+		return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+	}
+	// nil and empty slices are the same
+	if a.Kind() == reflect.Slice && b.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func userIDs(users []model.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+// returns values added to and removed from before, duplicates in after are added once
+func diffStrings(before, after []string) (added, removed []string) {
+	beforeSet := make(map[string]struct{}, len(before))
+	for _, s := range before {
+		beforeSet[s] = struct{}{}
+	}
+	afterSet := make(map[string]struct{}, len(after))
+	for _, s := range after {
+		if _, ok := afterSet[s]; ok {
+			continue
+		}
+		afterSet[s] = struct{}{}
+		if _, ok := beforeSet[s]; !ok {
+			added = append(added, s)
+		}
+	}
+	for _, s := range before {
+		if _, ok := afterSet[s]; !ok {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// links are identified by target and role, changed revision or suspect flag
+// means that link is removed and added again
+func diffLinks(before, after []model.Link) (added, removed []model.Link) {
+	type linkKey struct{ uri, role string }
+
+	beforeLinks := make(map[linkKey]model.Link, len(before))
+	for _, l := range before {
+		beforeLinks[linkKey{l.URI, l.Role}] = l
+	}
+	afterLinks := make(map[linkKey]model.Link, len(after))
+	for _, l := range after {
+		key := linkKey{l.URI, l.Role}
+		afterLinks[key] = l
+		if previous, ok := beforeLinks[key]; !ok || previous != l {
+			added = append(added, l)
+		}
+	}
+	for _, l := range before {
+		if current, ok := afterLinks[linkKey{l.URI, l.Role}]; !ok || current != l {
+			removed = append(removed, l)
+		}
+	}
+	return added, removed
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
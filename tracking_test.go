@@ -0,0 +1,139 @@
+package polarion_wsdl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+)
+
+func TestTrackedWorkItemCustomChanges(t *testing.T) {
+	unsupported := &CustomValue{XSIType: "tracker:Unknown", InnerXML: "<x>1</x>"}
+	day := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		change func(custom map[string]any)
+		want   map[string]any
+	}{
+		{"unchanged", func(map[string]any) {}, map[string]any{}},
+		{
+			"same date in other time zone",
+			func(custom map[string]any) {
+				custom["due"] = Date{time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)}
+			},
+			map[string]any{},
+		},
+		{
+			"other date",
+			func(custom map[string]any) { custom["due"] = Date{day.AddDate(0, 0, 1)} },
+			map[string]any{"due": Date{day.AddDate(0, 0, 1)}},
+		},
+		{
+			"time set to date field",
+			func(custom map[string]any) { custom["due"] = day.AddDate(0, 0, 1) },
+			map[string]any{"due": Date{day.AddDate(0, 0, 1)}},
+		},
+		{
+			"string set to enum field",
+			func(custom map[string]any) { custom["asil"] = "C" },
+			map[string]any{"asil": EnumID("C")},
+		},
+		{
+			"unsupported value replaced",
+			func(custom map[string]any) { custom["other"] = "x" },
+			map[string]any{"other": "x"},
+		},
+		{
+			"field removed",
+			func(custom map[string]any) { delete(custom, "asil") },
+			map[string]any{"asil": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wi := &WorkItem{CustomFields: &ArrayOfCustom{Custom: []*Custom{
+				{Key: "asil", Value: &CustomValue{XSIType: "tracker:EnumOptionId", InnerXML: "<id>B</id>"}},
+				{Key: "due", Value: &CustomValue{XSIType: "xsd:date", InnerXML: "2024-04-01"}},
+				{Key: "other", Value: unsupported},
+			}}}
+			wi.Uri = model.NewURI("subterra:data-service:objects:/default/demo${WorkItem}DEMO-1")
+
+			tracked, err := (&Polarion{}).Track(wi)
+			if err != nil {
+				t.Fatalf("Track: %v", err)
+			}
+			if !reflect.DeepEqual(tracked.Custom["other"], unsupported) {
+				t.Errorf("unsupported value tracked as %#v", tracked.Custom["other"])
+			}
+
+			tt.change(tracked.Custom)
+			changes, err := tracked.Changes()
+			if err != nil {
+				t.Fatalf("Changes: %v", err)
+			}
+			if !reflect.DeepEqual(changes.Custom, tt.want) {
+				t.Errorf("custom changes %v, want %v", changes.Custom, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameValue(t *testing.T) {
+	instant := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	text := "a"
+
+	tests := []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{"equal strings", "a", "a", true},
+		{"different strings", "a", "b", false},
+		{"pointer and value", &text, "a", true},
+		{"nil and zero", nil, "", true},
+		{"nil and value", nil, "a", false},
+		{"same instant", instant, instant.In(time.FixedZone("X", 3600)), true},
+		{"same day", Date{instant}, Date{time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)}, true},
+		{"other day", Date{instant}, Date{instant.AddDate(0, 0, 1)}, false},
+		{"nil and empty slice", []string(nil), []string{}, true},
+		{
+			"raw values",
+			&CustomValue{XSIType: "tracker:X", InnerXML: "1"},
+			&CustomValue{XSIType: "tracker:X", InnerXML: "1"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameValue(reflect.ValueOf(tt.a), reflect.ValueOf(tt.b)); got != tt.want {
+				t.Errorf("sameValue(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStrings(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after []string
+		added         []string
+		removed       []string
+	}{
+		{"unchanged", []string{"a", "b"}, []string{"b", "a"}, nil, nil},
+		{"added and removed", []string{"a", "b"}, []string{"b", "c"}, []string{"c"}, []string{"a"}},
+		{"duplicates added once", nil, []string{"a", "a", "b"}, []string{"a", "b"}, nil},
+		{"all removed", []string{"a"}, nil, nil, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diffStrings(tt.before, tt.after)
+			if !reflect.DeepEqual(added, tt.added) || !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("diffStrings() = %v, %v, want %v, %v", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
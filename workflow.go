@@ -0,0 +1,487 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// default maximal number of workflow actions performed by TransitionTo
+const defaultTransitionSteps = 10
+
+// how long learned transitions are used when TTL is not set with SetWorkflowCacheTTL
+const defaultWorkflowTTL = 10 * time.Minute
+
+// ErrTransitionUnavailable is returned (wrapped in *TransitionUnavailableError)
+// when workflow action exists but can not be performed on work item
+var ErrTransitionUnavailable = errors.New("workflow action is unavailable")
+
+// ErrNoTransitionPath is returned when no known sequence of workflow actions leads to target status
+var ErrNoTransitionPath = errors.New("no workflow path to target status")
+
+type TransitionUnavailableError struct {
+	URI          string
+	Action       string
+	TargetStatus string
+
+	// reason reported by Polarion
+	Message string
+}
+
+func (e *TransitionUnavailableError) Error() string {
+	return fmt.Sprintf(
+		"%v: action '%s' to status '%s' of %s: %s",
+		ErrTransitionUnavailable, e.Action, e.TargetStatus, e.URI, e.Message,
+	)
+}
+
+func (e *TransitionUnavailableError) Is(target error) bool {
+	return target == ErrTransitionUnavailable
+}
+
+// WorkflowTransition is workflow action leading from one status to another
+type WorkflowTransition struct {
+	ActionID       int32  `json:"actionId"`
+	ActionName     string `json:"actionName"`
+	NativeActionID string `json:"nativeActionId,omitempty"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+
+	// fields which must be filled to perform the action
+	RequiredFeatures []string `json:"requiredFeatures,omitempty"`
+
+	// reason why action can not be performed on work item, empty for available actions
+	Unavailable string `json:"unavailable,omitempty"`
+}
+
+type TransitionOptions struct {
+	// values of required features keyed by work item field name,
+	// custom fields either with or without "customFields." prefix
+	Features map[string]any
+
+	// maximal number of actions performed to reach target status, 10 by default
+	MaxSteps int
+}
+
+// workflow of work item type in project
+type workflowKey struct {
+	projectID string
+	typeID    string
+}
+
+type workflowEntry struct {
+	transitions []WorkflowTransition
+	loaded      time.Time
+}
+
+// transitions learned from work items, shared by all workflow operations
+type workflowCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+
+	// transitions leading from status, explored statuses without transitions have nil slice
+	entries map[workflowKey]map[string]workflowEntry
+}
+
+func (c *workflowCache) get(key workflowKey, status string) ([]WorkflowTransition, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.ttl
+	if ttl == 0 {
+		ttl = defaultWorkflowTTL
+	}
+	entry, ok := c.entries[key][status]
+	if !ok || time.Since(entry.loaded) > ttl {
+		return nil, false
+	}
+	return entry.transitions, true
+}
+
+func (c *workflowCache) set(key workflowKey, status string, transitions []WorkflowTransition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[workflowKey]map[string]workflowEntry{}
+	}
+	if c.entries[key] == nil {
+		c.entries[key] = map[string]workflowEntry{}
+	}
+
+	entry := workflowEntry{loaded: time.Now()}
+	if transitions != nil {
+		// unavailability is specific to work item the transitions were read from
+		entry.transitions = make([]WorkflowTransition, len(transitions))
+		for i, transition := range transitions {
+			transition.Unavailable = ""
+			entry.transitions[i] = transition
+		}
+	}
+	c.entries[key][status] = entry
+}
+
+// SetWorkflowCacheTTL sets how long learned workflow transitions are cached, negative TTL disables caching
+func (p *Polarion) SetWorkflowCacheTTL(ttl time.Duration) {
+	p.workflows.mu.Lock()
+	defer p.workflows.mu.Unlock()
+	p.workflows.ttl = ttl
+}
+
+// InvalidateWorkflowCache drops all learned workflow transitions, e.g. after workflow configuration changed
+func (p *Polarion) InvalidateWorkflowCache() {
+	p.workflows.mu.Lock()
+	defer p.workflows.mu.Unlock()
+	p.workflows.entries = nil
+}
+
+// TransitionTo performs workflow actions until work item gets to target status.
+// Direct action is preferred, otherwise shortest path is searched in workflow
+// learned from other work items of the same type. Performed transitions are returned
+// also when later step fails.
+func (p *Polarion) TransitionTo(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	targetStatus string,
+	opts *TransitionOptions,
+) ([]WorkflowTransition, error) {
+	if uri == nil {
+		return nil, fmt.Errorf("work item URI is required for transition")
+	}
+	maxSteps := defaultTransitionSteps
+	var features map[string]any
+	if opts != nil {
+		features = opts.Features
+		if opts.MaxSteps > 0 {
+			maxSteps = opts.MaxSteps
+		}
+	}
+
+	var performed []WorkflowTransition
+	for {
+		key, status, err := p.workflowState(ctx, uri)
+		if err != nil {
+			return performed, err
+		}
+		if status == targetStatus {
+			return performed, nil
+		}
+		if len(performed) > 0 && performed[len(performed)-1].From == status {
+			return performed, fmt.Errorf(
+				"work item %s stayed in status '%s' after action '%s'",
+				model.URI(uri), status, performed[len(performed)-1].ActionName,
+			)
+		}
+		if len(performed) >= maxSteps {
+			return performed, fmt.Errorf(
+				"%w: '%s' not reached from '%s' within %d actions", ErrNoTransitionPath, targetStatus, status, maxSteps,
+			)
+		}
+
+		transitions, err := p.workItemTransitions(ctx, uri, status)
+		if err != nil {
+			return performed, err
+		}
+		p.workflows.set(key, status, transitions)
+
+		next := targetStatus
+		if !leadsTo(availableTransitions(transitions), targetStatus) {
+			path, err := p.workflowPath(ctx, key, status, targetStatus, transitions)
+			if err != nil {
+				return performed, err
+			}
+			if len(path) == 0 && leadsTo(transitions, targetStatus) {
+				// only direct action is unavailable, its reason is reported
+				_, err := selectTransition(transitions, targetStatus, uri)
+				return performed, err
+			}
+			if len(path) == 0 {
+				return performed, fmt.Errorf(
+					"%w: '%s' from '%s' for %s", ErrNoTransitionPath, targetStatus, status, model.URI(uri),
+				)
+			}
+			next = path[0]
+		}
+
+		transition, err := selectTransition(transitions, next, uri)
+		if err != nil {
+			return performed, err
+		}
+		if err := p.fillRequiredFeatures(ctx, uri, transition.RequiredFeatures, features); err != nil {
+			return performed, err
+		}
+
+		req := tracker_ws.PerformWorkflowAction{
+			WorkitemURI: uri,
+			ActionId:    transition.ActionID,
+		}
+		if _, err := p.TrackerWS.PerformWorkflowActionContext(ctx, &req); err != nil {
+			return performed, fmt.Errorf(
+				"failed to perform action '%s' on work item %s: %v", transition.ActionName, model.URI(uri), err,
+			)
+		}
+		performed = append(performed, transition)
+	}
+}
+
+// reads workflow and current status of work item
+func (p *Polarion) workflowState(ctx context.Context, uri *tracker_ws.SubterraURI) (workflowKey, string, error) {
+	wi, err := p.workItemFields(ctx, uri, "project", "type", "status")
+	if err != nil {
+		return workflowKey{}, "", err
+	}
+
+	key := workflowKey{projectID: projectIDOf(wi.Project), typeID: model.EnumID(wi.Type_)}
+	return key, model.EnumID(wi.Status), nil
+}
+
+// available and unavailable actions of work item
+func (p *Polarion) workItemTransitions(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	status string,
+) ([]WorkflowTransition, error) {
+	available, err := p.TrackerWS.GetAvailableActionsContext(ctx, &tracker_ws.GetAvailableActions{WorkitemURI: uri})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available actions of work item %s: %v", model.URI(uri), err)
+	}
+	unavailable, err := p.TrackerWS.GetUnavailableActionsContext(ctx, &tracker_ws.GetUnavailableActions{WorkitemURI: uri})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unavailable actions of work item %s: %v", model.URI(uri), err)
+	}
+
+	// non-nil also without actions, nil marks statuses without work items
+	transitions := []WorkflowTransition{}
+	for _, action := range available.GetAvailableActionsReturn {
+		transitions = append(transitions, transitionFromWS(action, status))
+	}
+	for _, action := range unavailable.GetUnavailableActionsReturn {
+		transition := transitionFromWS(action, status)
+		if transition.Unavailable == "" {
+			transition.Unavailable = "no reason reported"
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions, nil
+}
+
+func transitionFromWS(action *tracker_ws.WorkflowAction, from string) WorkflowTransition {
+	transition := WorkflowTransition{
+		ActionID: action.ActionId,
+		From:     from,
+	}
+	if action.ActionName != nil {
+		transition.ActionName = *action.ActionName
+	}
+	if action.NativeActionId != nil {
+		transition.NativeActionID = *action.NativeActionId
+	}
+	if action.TargetStatus != nil && action.TargetStatus.Id != nil {
+		transition.To = *action.TargetStatus.Id
+	}
+	if action.RequiredFeatures != nil {
+		transition.RequiredFeatures = action.RequiredFeatures.Item
+	}
+	if action.UnavailabilityMessage != nil {
+		transition.Unavailable = *action.UnavailabilityMessage
+	}
+	return transition
+}
+
+func availableTransitions(transitions []WorkflowTransition) []WorkflowTransition {
+	var available []WorkflowTransition
+	for _, transition := range transitions {
+		if transition.Unavailable == "" {
+			available = append(available, transition)
+		}
+	}
+	return available
+}
+
+func leadsTo(transitions []WorkflowTransition, status string) bool {
+	for _, transition := range transitions {
+		if transition.To == status {
+			return true
+		}
+	}
+	return false
+}
+
+// available transition to status, if there is only unavailable one its message is returned as error
+func selectTransition(
+	transitions []WorkflowTransition,
+	status string,
+	uri *tracker_ws.SubterraURI,
+) (WorkflowTransition, error) {
+	var unavailable *WorkflowTransition
+	for i, transition := range transitions {
+		if transition.To != status {
+			continue
+		}
+		if transition.Unavailable == "" {
+			return transition, nil
+		}
+		if unavailable == nil {
+			unavailable = &transitions[i]
+		}
+	}
+
+	if unavailable == nil {
+		return WorkflowTransition{}, fmt.Errorf("%w: '%s' for %s", ErrNoTransitionPath, status, model.URI(uri))
+	}
+	return WorkflowTransition{}, &TransitionUnavailableError{
+		URI:          model.URI(uri),
+		Action:       unavailable.ActionName,
+		TargetStatus: status,
+		Message:      unavailable.Unavailable,
+	}
+}
+
+// shortest sequence of statuses (without from) leading to target, path starts
+// with available transitions of work item, statuses not known yet are explored
+// on first work item found in them
+func (p *Polarion) workflowPath(
+	ctx context.Context,
+	key workflowKey,
+	from, to string,
+	fromTransitions []WorkflowTransition,
+) ([]string, error) {
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		status := queue[0]
+		queue = queue[1:]
+
+		transitions := availableTransitions(fromTransitions)
+		if status != from {
+			var err error
+			if transitions, err = p.statusTransitions(ctx, key, status); err != nil {
+				return nil, err
+			}
+		}
+		for _, transition := range transitions {
+			if _, seen := previous[transition.To]; seen {
+				continue
+			}
+			previous[transition.To] = status
+			if transition.To != to {
+				queue = append(queue, transition.To)
+				continue
+			}
+
+			var path []string
+			for s := to; s != from; s = previous[s] {
+				path = append([]string{s}, path...)
+			}
+			return path, nil
+		}
+	}
+	return nil, nil
+}
+
+// transitions from status in workflow, read from sample work item if not cached
+func (p *Polarion) statusTransitions(ctx context.Context, key workflowKey, status string) ([]WorkflowTransition, error) {
+	if transitions, ok := p.workflows.get(key, status); ok {
+		return transitions, nil
+	}
+
+	req := tracker_ws.QueryWorkItemUrisLimited{
+		Query: fmt.Sprintf(
+			"project.id:%s AND type:%s AND status:%s",
+			luceneQuote(key.projectID), luceneQuote(key.typeID), luceneQuote(status),
+		),
+		ResultsLimit: 1,
+	}
+	resp, err := p.TrackerWS.QueryWorkItemUrisLimitedContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find '%s' work item in status '%s': %v", key.typeID, status, err)
+	}
+
+	var transitions []WorkflowTransition
+	if len(resp.QueryWorkItemUrisLimitedReturn) > 0 {
+		uri := tracker_ws.SubterraURI(resp.QueryWorkItemUrisLimitedReturn[0])
+		if transitions, err = p.workItemTransitions(ctx, &uri, status); err != nil {
+			return nil, err
+		}
+	}
+	p.workflows.set(key, status, transitions)
+	return transitions, nil
+}
+
+// sets required features from supplied values, features without value
+// must already be filled in work item, otherwise *MissingFieldsError is returned
+func (p *Polarion) fillRequiredFeatures(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	required []string,
+	values map[string]any,
+) error {
+	changes := FieldMask{}
+	var standard, custom []string
+	for _, feature := range required {
+		name := feature
+		if _, ok := workItemFieldIndex[feature]; !ok {
+			name = customFieldsPrefix + feature
+		}
+
+		if value, ok := values[feature]; ok {
+			changes[name] = value
+		} else if value, ok := values[name]; ok {
+			changes[name] = value
+		} else if name == feature {
+			standard = append(standard, feature)
+		} else {
+			custom = append(custom, feature)
+		}
+	}
+
+	missing, err := p.emptyFields(ctx, uri, standard, custom)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return &MissingFieldsError{Fields: missing}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err = p.UpdateWorkItem(ctx, uri, changes)
+	return err
+}
+
+// names of standard and custom fields without value in work item
+func (p *Polarion) emptyFields(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	standard, custom []string,
+) ([]string, error) {
+	var empty []string
+	if len(standard) > 0 {
+		wi, err := p.workItemFields(ctx, uri, standard...)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range standard {
+			if reflect.ValueOf(wi).Elem().Field(workItemFieldIndex[name]).IsZero() {
+				empty = append(empty, name)
+			}
+		}
+	}
+
+	for _, key := range custom {
+		value, err := p.GetCustomFieldValue(ctx, uri, key)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			empty = append(empty, customFieldsPrefix+key)
+		}
+	}
+	return empty, nil
+}
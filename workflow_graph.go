@@ -0,0 +1,206 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// WorkflowGraph is state machine of work item type discovered from Polarion
+type WorkflowGraph struct {
+	ProjectID string `json:"projectId"`
+	Type      string `json:"type"`
+
+	// status of newly created work items and action leading to it
+	InitialStatus string `json:"initialStatus,omitempty"`
+	InitialAction string `json:"initialAction,omitempty"`
+
+	Statuses    []WorkflowStatus     `json:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+type WorkflowStatus struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+
+	// false if there was no work item in this status, so its actions are not known
+	Explored bool `json:"explored"`
+}
+
+// DiscoverWorkflows discovers workflows of given work item types,
+// all types of project are discovered if none is given
+func (p *Polarion) DiscoverWorkflows(
+	ctx context.Context,
+	projectID string,
+	typeIDs ...string,
+) ([]*WorkflowGraph, error) {
+	if len(typeIDs) == 0 {
+		types, err := p.EnumOptions(ctx, projectID, "", "type")
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range types {
+			typeIDs = append(typeIDs, option.ID)
+		}
+	}
+
+	var graphs []*WorkflowGraph
+	for _, typeID := range typeIDs {
+		graph, err := p.DiscoverWorkflow(ctx, projectID, typeID)
+		if err != nil {
+			return nil, err
+		}
+		graphs = append(graphs, graph)
+	}
+	return graphs, nil
+}
+
+// DiscoverWorkflow walks statuses of work item type starting with initial status.
+// Actions of each status are read from one of work items in that status, statuses
+// without work items stay unexplored, as Polarion does not expose workflow definition.
+// Transitions learned earlier are reused until they expire (see SetWorkflowCacheTTL),
+// call InvalidateWorkflowCache to discover changed workflow configuration.
+func (p *Polarion) DiscoverWorkflow(ctx context.Context, projectID, typeID string) (*WorkflowGraph, error) {
+	graph := &WorkflowGraph{ProjectID: projectID, Type: typeID}
+	key := workflowKey{projectID: projectID, typeID: typeID}
+
+	req := tracker_ws.GetInitialWorkflowActionForProjectAndType{
+		ProjectId: projectID,
+		WiType:    &tracker_ws.EnumOptionId{Id: &typeID},
+	}
+	resp, err := p.TrackerWS.GetInitialWorkflowActionForProjectAndTypeContext(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get initial workflow action of '%s' in project '%s': %v", typeID, projectID, err)
+	}
+	if action := resp.GetInitialWorkflowActionForProjectAndTypeReturn; action != nil {
+		initial := transitionFromWS(action, "")
+		graph.InitialStatus, graph.InitialAction = initial.To, initial.ActionName
+	}
+
+	options, err := p.EnumOptions(ctx, projectID, typeID, "status")
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]string{}
+	var queue []string
+	if graph.InitialStatus != "" {
+		queue = append(queue, graph.InitialStatus)
+	}
+	for _, option := range options {
+		names[option.ID] = option.Name
+		queue = append(queue, option.ID)
+	}
+
+	seen := map[string]struct{}{}
+	for len(queue) > 0 {
+		status := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[status]; ok {
+			continue
+		}
+		seen[status] = struct{}{}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		transitions, err := p.statusTransitions(ctx, key, status)
+		if err != nil {
+			return nil, err
+		}
+
+		graph.Statuses = append(graph.Statuses, WorkflowStatus{
+			ID:   status,
+			Name: names[status],
+			// statuses without work items are cached without transitions
+			Explored: transitions != nil,
+		})
+		graph.Transitions = append(graph.Transitions, transitions...)
+		for _, transition := range transitions {
+			queue = append(queue, transition.To)
+		}
+	}
+
+	return graph, nil
+}
+
+func (g *WorkflowGraph) label(status string) string {
+	for _, s := range g.Statuses {
+		if s.ID == status && s.Name != "" {
+			return s.Name
+		}
+	}
+	return status
+}
+
+// DOT renders graph in Graphviz format, unexplored statuses are dashed
+func (g *WorkflowGraph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(g.ProjectID+" "+g.Type))
+	b.WriteString("  rankdir=LR;\n")
+
+	if g.InitialStatus != "" {
+		b.WriteString("  \"__start\" [shape=point];\n")
+		fmt.Fprintf(&b, "  \"__start\" -> %s [label=%s];\n", strconv.Quote(g.InitialStatus), strconv.Quote(g.InitialAction))
+	}
+	for _, status := range g.Statuses {
+		style := ""
+		if !status.Explored {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s%s];\n", strconv.Quote(status.ID), strconv.Quote(g.label(status.ID)), style)
+	}
+	for _, t := range g.Transitions {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", strconv.Quote(t.From), strconv.Quote(t.To), strconv.Quote(t.ActionName))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders graph as Mermaid state diagram
+func (g *WorkflowGraph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+
+	for _, status := range g.Statuses {
+		fmt.Fprintf(&b, "    state \"%s\" as %s\n", mermaidText(g.label(status.ID)), mermaidID(status.ID))
+	}
+	if g.InitialStatus != "" {
+		fmt.Fprintf(&b, "    [*] --> %s : %s\n", mermaidID(g.InitialStatus), mermaidText(g.InitialAction))
+	}
+	for _, t := range g.Transitions {
+		fmt.Fprintf(&b, "    %s --> %s : %s\n", mermaidID(t.From), mermaidID(t.To), mermaidText(t.ActionName))
+	}
+	return b.String()
+}
+
+func (g *WorkflowGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// state IDs may contain only letters, digits and underscores, so underscore is doubled
+// and other characters are written as hex code between underscores ("in-review" -> "s_in_2d_review")
+func mermaidID(id string) string {
+	var b strings.Builder
+	b.WriteString("s_")
+	for _, r := range id {
+		switch {
+		case r == '_':
+			b.WriteString("__")
+		case ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9'):
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "_%x_", r)
+		}
+	}
+	return b.String()
+}
+
+// quotes, colons and line breaks break Mermaid syntax
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "'", ":", " ", "\n", " ").Replace(s)
+}
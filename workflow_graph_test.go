@@ -0,0 +1,129 @@
+package polarion_wsdl
+
+import (
+	"testing"
+)
+
+// statuses "in-review" and "in_review" must stay separate states in all exports
+func testWorkflowGraph() *WorkflowGraph {
+	return &WorkflowGraph{
+		ProjectID:     "demo",
+		Type:          "task",
+		InitialStatus: "draft",
+		InitialAction: "Create",
+		Statuses: []WorkflowStatus{
+			{ID: "draft", Name: "Draft", Explored: true},
+			{ID: "in-review", Name: `In "review": now`, Explored: true},
+			{ID: "in_review", Explored: false},
+		},
+		Transitions: []WorkflowTransition{
+			{ActionID: 1, ActionName: "Submit", From: "draft", To: "in-review"},
+			{ActionID: 2, ActionName: "Park", From: "in-review", To: "in_review", RequiredFeatures: []string{"assignee"}},
+		},
+	}
+}
+
+func TestWorkflowGraphDOT(t *testing.T) {
+	want := `digraph "demo task" {
+  rankdir=LR;
+  "__start" [shape=point];
+  "__start" -> "draft" [label="Create"];
+  "draft" [label="Draft"];
+  "in-review" [label="In \"review\": now"];
+  "in_review" [label="in_review", style=dashed];
+  "draft" -> "in-review" [label="Submit"];
+  "in-review" -> "in_review" [label="Park"];
+}
+`
+	if got := testWorkflowGraph().DOT(); got != want {
+		t.Errorf("DOT() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWorkflowGraphMermaid(t *testing.T) {
+	want := `stateDiagram-v2
+    state "Draft" as s_draft
+    state "In 'review'  now" as s_in_2d_review
+    state "in_review" as s_in__review
+    [*] --> s_draft : Create
+    s_draft --> s_in_2d_review : Submit
+    s_in_2d_review --> s_in__review : Park
+`
+	if got := testWorkflowGraph().Mermaid(); got != want {
+		t.Errorf("Mermaid() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWorkflowGraphJSON(t *testing.T) {
+	want := `{
+  "projectId": "demo",
+  "type": "task",
+  "initialStatus": "draft",
+  "initialAction": "Create",
+  "statuses": [
+    {
+      "id": "draft",
+      "name": "Draft",
+      "explored": true
+    },
+    {
+      "id": "in-review",
+      "name": "In \"review\": now",
+      "explored": true
+    },
+    {
+      "id": "in_review",
+      "explored": false
+    }
+  ],
+  "transitions": [
+    {
+      "actionId": 1,
+      "actionName": "Submit",
+      "from": "draft",
+      "to": "in-review"
+    },
+    {
+      "actionId": 2,
+      "actionName": "Park",
+      "from": "in-review",
+      "to": "in_review",
+      "requiredFeatures": [
+        "assignee"
+      ]
+    }
+  ]
+}`
+	got, err := testWorkflowGraph().JSON()
+	if err != nil {
+		t.Fatalf("JSON() error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("JSON() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMermaidID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"open", "s_open"},
+		{"in-review", "s_in_2d_review"},
+		{"in_review", "s_in__review"},
+		{"in_2d_review", "s_in__2d__review"},
+		{"zrušeno", "s_zru_161_eno"},
+	}
+
+	seen := map[string]string{}
+	for _, tt := range tests {
+		got := mermaidID(tt.id)
+		if got != tt.want {
+			t.Errorf("mermaidID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+		if other, ok := seen[got]; ok {
+			t.Errorf("mermaidID(%q) collides with %q", tt.id, other)
+		}
+		seen[got] = tt.id
+	}
+}
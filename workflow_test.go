@@ -0,0 +1,127 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+)
+
+func TestWorkflowCache(t *testing.T) {
+	key := workflowKey{projectID: "demo", typeID: "task"}
+	transitions := []WorkflowTransition{{ActionID: 1, From: "open", To: "done", Unavailable: "locked"}}
+
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		loaded time.Time
+		want   bool
+	}{
+		{"fresh", 0, time.Now(), true},
+		{"expired default TTL", 0, time.Now().Add(-defaultWorkflowTTL - time.Second), false},
+		{"custom TTL", time.Hour, time.Now().Add(-30 * time.Minute), true},
+		{"disabled", -1, time.Now(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Polarion{}
+			p.SetWorkflowCacheTTL(tt.ttl)
+			p.workflows.set(key, "open", transitions)
+			entry := p.workflows.entries[key]["open"]
+			entry.loaded = tt.loaded
+			p.workflows.entries[key]["open"] = entry
+
+			cached, ok := p.workflows.get(key, "open")
+			if ok != tt.want {
+				t.Fatalf("cached %v, want %v", ok, tt.want)
+			}
+			if ok && (len(cached) != 1 || cached[0].Unavailable != "") {
+				t.Errorf("cached transitions %+v keep unavailability", cached)
+			}
+		})
+	}
+
+	p := &Polarion{}
+	p.workflows.set(key, "open", transitions)
+	p.InvalidateWorkflowCache()
+	if _, ok := p.workflows.get(key, "open"); ok {
+		t.Error("transitions cached after invalidation")
+	}
+}
+
+func TestWorkflowPath(t *testing.T) {
+	key := workflowKey{projectID: "demo", typeID: "task"}
+	p := &Polarion{}
+	p.workflows.set(key, "review", []WorkflowTransition{{To: "done"}, {To: "open"}})
+	p.workflows.set(key, "blocked", []WorkflowTransition{})
+	p.workflows.set(key, "done", []WorkflowTransition{})
+
+	tests := []struct {
+		name        string
+		transitions []WorkflowTransition
+		want        []string
+	}{
+		{
+			"unavailable direct action",
+			[]WorkflowTransition{{To: "done", Unavailable: "missing resolution"}, {To: "review"}},
+			[]string{"review", "done"},
+		},
+		{
+			"only unavailable actions",
+			[]WorkflowTransition{{To: "done", Unavailable: "locked"}, {To: "review", Unavailable: "locked"}},
+			nil,
+		},
+		{
+			"dead end",
+			[]WorkflowTransition{{To: "blocked"}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := p.workflowPath(context.Background(), key, "open", "done", tt.transitions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(path, tt.want) {
+				t.Errorf("path %v, want %v", path, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectTransition(t *testing.T) {
+	uri := model.NewURI("subterra:data-service:objects:/default/demo${WorkItem}DEMO-1")
+	transitions := []WorkflowTransition{
+		{ActionID: 1, ActionName: "close", To: "done", Unavailable: "locked"},
+		{ActionID: 2, ActionName: "resolve", To: "done"},
+		{ActionID: 3, ActionName: "block", To: "blocked", Unavailable: "not allowed"},
+	}
+
+	tests := []struct {
+		status     string
+		wantAction int32
+		wantErr    error
+	}{
+		{"done", 2, nil},
+		{"blocked", 0, ErrTransitionUnavailable},
+		{"review", 0, ErrNoTransitionPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			transition, err := selectTransition(transitions, tt.status, uri)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if transition.ActionID != tt.wantAction {
+				t.Errorf("action %d, want %d", transition.ActionID, tt.wantAction)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+)
+
+type getWorkItemByIdResponse struct {
+	XMLName xml.Name `xml:"http://ws.polarion.com/TrackerWebService-impl getWorkItemByIdResponse"`
+
+	GetWorkItemByIdReturn *WorkItem `xml:"getWorkItemByIdReturn,omitempty"`
+}
+
+// WorkItemModel converts work item to model.WorkItem including custom fields,
+// which are decoded with DecodeCustomValue and keep XSI type of received value
+func WorkItemModel(wi *WorkItem) (*model.WorkItem, error) {
+	if wi == nil {
+		return nil, nil
+	}
+
+	item := model.WorkItemFromWS(&wi.WorkItem)
+	if wi.CustomFields == nil {
+		return item, nil
+	}
+
+	item.CustomFields = make(map[string]model.CustomField, len(wi.CustomFields.Custom))
+	for _, field := range wi.CustomFields.Custom {
+		if field == nil {
+			continue
+		}
+		value, err := DecodeCustomValue(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode custom field '%s' of work item %s: %v", field.Key, wi.Id, err)
+		}
+		custom := model.CustomField{Value: value}
+		if field.Value != nil {
+			custom.Type = field.Value.XSIType
+		}
+		item.CustomFields[field.Key] = custom
+	}
+	return item, nil
+}
+
+// WorkItemFromModel converts model.WorkItem back to work item,
+// custom field values are encoded as type they were received with
+func WorkItemFromModel(item *model.WorkItem) (*WorkItem, error) {
+	if item == nil {
+		return nil, nil
+	}
+
+	wi := &WorkItem{WorkItem: *item.ToWS()}
+	if item.CustomFields == nil {
+		return wi, nil
+	}
+
+	wi.CustomFields = &ArrayOfCustom{Custom: make([]*Custom, 0, len(item.CustomFields))}
+	for _, key := range sortedKeys(item.CustomFields) {
+		field := item.CustomFields[key]
+		value, err := EncodeCustomValue(customValueAs(field.Value, field.Type))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode custom field '%s' of work item %s: %v", key, item.ID, err)
+		}
+		wi.CustomFields.Custom = append(wi.CustomFields.Custom, &Custom{Key: key, Value: value})
+	}
+	return wi, nil
+}
@@ -0,0 +1,78 @@
+package polarion_wsdl
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+)
+
+func TestWorkItemModelRoundTrip(t *testing.T) {
+	wi := unmarshalWorkItem(t, `<workItem uri="subterra:data-service:objects:/default/demo${WorkItem}DEMO-1">
+		<id>DEMO-1</id>
+		<status><id>open</id></status>
+		<customFields>
+			<Custom><key>asil</key><value `+mappingCustomValueAttrs+` xsi:type="tracker:EnumOptionId"><id>D</id></value></Custom>
+			<Custom><key>reviewDate</key><value `+mappingCustomValueAttrs+` xsi:type="xsd:date">2024-04-01</value></Custom>
+			<Custom><key>notes</key><value `+mappingCustomValueAttrs+` xsi:type="tracker:Text"><type>text/plain</type><content>checked</content></value></Custom>
+		</customFields>
+	</workItem>`)
+
+	item, err := WorkItemModel(wi)
+	if err != nil {
+		t.Fatalf("WorkItemModel() error: %v", err)
+	}
+	want := map[string]model.CustomField{
+		"asil":       {Type: "tracker:EnumOptionId", Value: EnumID("D")},
+		"reviewDate": {Type: "xsd:date", Value: time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)},
+		"notes":      {Type: "tracker:Text", Value: model.NewPlainText("checked")},
+	}
+	if !reflect.DeepEqual(item.CustomFields, want) {
+		t.Fatalf("custom fields = %#v, want %#v", item.CustomFields, want)
+	}
+
+	// value set as plain string is written as option of enum field
+	item.CustomFields["asil"] = model.CustomField{Type: "tracker:EnumOptionId", Value: "C"}
+	want["asil"] = model.CustomField{Type: "tracker:EnumOptionId", Value: EnumID("C")}
+
+	back, err := WorkItemFromModel(item)
+	if err != nil {
+		t.Fatalf("WorkItemFromModel() error: %v", err)
+	}
+	// work item goes through XML as it would be sent to and received from Polarion
+	b, err := xml.Marshal(back)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	item, err = WorkItemModel(unmarshalWorkItem(t, string(b)))
+	if err != nil {
+		t.Fatalf("WorkItemModel() of %s error: %v", b, err)
+	}
+
+	if item.ID != "DEMO-1" || item.Status != "open" {
+		t.Errorf("standard fields not kept: %+v", item)
+	}
+	if !reflect.DeepEqual(item.CustomFields, want) {
+		t.Errorf("custom fields after round trip = %#v, want %#v", item.CustomFields, want)
+	}
+}
+
+func TestWorkItemModelWithoutCustomFields(t *testing.T) {
+	item, err := WorkItemModel(unmarshalWorkItem(t, `<workItem><id>DEMO-1</id></workItem>`))
+	if err != nil {
+		t.Fatalf("WorkItemModel() error: %v", err)
+	}
+	if item.CustomFields != nil {
+		t.Errorf("custom fields = %#v, want nil", item.CustomFields)
+	}
+
+	wi, err := WorkItemFromModel(item)
+	if err != nil {
+		t.Fatalf("WorkItemFromModel() error: %v", err)
+	}
+	if wi.CustomFields != nil {
+		t.Errorf("encoded custom fields = %#v, want nil", wi.CustomFields)
+	}
+}
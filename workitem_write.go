@@ -0,0 +1,296 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+	"github.com/AVaitkunas/polarion-wsdl/tracker_ws"
+)
+
+// work item fields which are set by Polarion and can not be updated
+var readOnlyWorkItemFields = map[string]struct{}{
+	"uri":                    {},
+	"unresolvable":           {},
+	"id":                     {},
+	"created":                {},
+	"updated":                {},
+	"author":                 {},
+	"project":                {},
+	"previousStatus":         {},
+	"outlineNumber":          {},
+	"linkedWorkItemsDerived": {},
+	"linkedRevisionsDerived": {},
+}
+
+// FieldMask lists work item fields to update with their new values.
+// Keys are WorkItem field names ("status", "assignee") or
+// custom fields ("customFields.asil"), values are converted the same way
+// as in Encode, strings set to enum custom fields are option IDs.
+// nil (or nil pointer, map or slice) clears the field, other values are written,
+// 0 and false included. Standard fields can not be sent empty,
+// so empty string or zero time clears them too.
+type FieldMask map[string]any
+
+// WriteResult identifies work item after write operation
+type WriteResult struct {
+	URI *tracker_ws.SubterraURI
+
+	// time of the update reported by Polarion, zero for deleted work items
+	Updated time.Time
+}
+
+// project URI as expected in work item content
+func projectURI(projectID string) *tracker_ws.SubterraURI {
+	uri := tracker_ws.SubterraURI(
+		fmt.Sprintf("subterra:data-service:objects:/default/%s${Project}%s", projectID, projectID),
+	)
+	return &uri
+}
+
+// project ID from project reference of work item, unresolved projects have only URI
+func projectIDOf(project *tracker_ws.Project) string {
+	if project == nil {
+		return ""
+	}
+	if project.Id != "" || project.Uri == nil {
+		return project.Id
+	}
+	_, id, _ := strings.Cut(string(*project.Uri), "${Project}")
+	return id
+}
+
+// CreateWorkItem creates work item in project, item must have type set.
+// If work item is created but its updated timestamp can not be read back,
+// result with URI of created work item is returned together with the error.
+func (p *Polarion) CreateWorkItem(
+	ctx context.Context,
+	projectID string,
+	item *WorkItem,
+) (*WriteResult, error) {
+	if item == nil {
+		return nil, fmt.Errorf("cannot create nil work item")
+	}
+
+	content := *item
+	content.Uri = nil
+	content.Project = &tracker_ws.Project{Uri: projectURI(projectID)}
+
+	req := createWorkItemRequest{
+		Content: &content,
+	}
+	resp := &tracker_ws.CreateWorkItemResponse{}
+	if err := p.TrackerClient.CallContext(ctx, "''", &req, resp); err != nil {
+		return nil, fmt.Errorf("failed to create work item in project '%s': %v", projectID, err)
+	}
+
+	uri := tracker_ws.SubterraURI(resp.CreateWorkItemReturn)
+	return p.writeResult(ctx, &uri)
+}
+
+// UpdateWorkItem updates only fields listed in changes, cleared fields
+// are set with SetFieldsNull (see FieldMask). If update is written but updated
+// timestamp can not be read back, result with URI is returned together with the error.
+func (p *Polarion) UpdateWorkItem(
+	ctx context.Context,
+	uri *tracker_ws.SubterraURI,
+	changes FieldMask,
+) (*WriteResult, error) {
+	if uri == nil {
+		return nil, fmt.Errorf("work item URI is required for update")
+	}
+
+	content, nullFields, err := p.contentFromMask(ctx, uri, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	if content != nil {
+		if err := p.updateWorkItem(ctx, uri, content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.setFieldsNull(ctx, uri, nullFields); err != nil {
+		return nil, err
+	}
+
+	return p.writeResult(ctx, uri)
+}
+
+// DeleteWorkItems deletes all work items, failures do not stop deletion of remaining items
+// and are returned joined after results of successfully deleted items
+func (p *Polarion) DeleteWorkItems(
+	ctx context.Context,
+	uris []*tracker_ws.SubterraURI,
+) ([]WriteResult, error) {
+	var results []WriteResult
+	var errs []error
+
+	for _, uri := range uris {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		req := tracker_ws.DeleteWorkItem{
+			WorkitemURI: uri,
+		}
+		if _, err := p.TrackerWS.DeleteWorkItemContext(ctx, &req); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete work item %s: %v", model.URI(uri), err))
+			continue
+		}
+		results = append(results, WriteResult{URI: uri})
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// splits field mask to work item content with values to update
+// and names of fields to clear, content is nil if nothing is updated
+func (p *Polarion) contentFromMask(ctx context.Context, uri *tracker_ws.SubterraURI, changes FieldMask) (*WorkItem, []string, error) {
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	// deterministic order of requests and errors
+	sort.Strings(names)
+
+	if err := p.ValidateWorkItemFields(ctx, uriProjectID(uri), names); err != nil {
+		return nil, nil, err
+	}
+
+	item := &model.WorkItem{}
+	// loaded when string value is set to custom field, strings of enum fields are option IDs
+	var fieldTypes map[string]*customFieldType
+	var customFields []*Custom
+	var nullFields []string
+	for _, name := range names {
+		if _, ok := readOnlyWorkItemFields[name]; ok || name == "customFields" {
+			return nil, nil, fmt.Errorf("work item field '%s' can not be updated", name)
+		}
+
+		value := reflect.ValueOf(changes[name])
+		if isNilValue(value) {
+			nullFields = append(nullFields, name)
+			continue
+		}
+
+		if key, ok := strings.CutPrefix(name, customFieldsPrefix); ok {
+			field := mappedField{name: key}
+			if reflect.Indirect(value).Kind() == reflect.String {
+				if fieldTypes == nil {
+					types, err := p.customFieldTypes(ctx, uri)
+					if err != nil {
+						return nil, nil, err
+					}
+					fieldTypes = types
+				}
+				field.enum = fieldTypes[key] != nil && fieldTypes[key].EnumID != ""
+			}
+
+			encoded, err := EncodeCustomValue(customGoValue(value, field))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to encode work item custom field '%s': %v", key, err)
+			}
+			customFields = append(customFields, &Custom{Key: key, Value: encoded})
+			continue
+		}
+
+		field := modelField(item, name)
+		if err := assignValue(field, value); err != nil {
+			return nil, nil, fmt.Errorf("failed to set work item field '%s': %v", name, err)
+		}
+		// empty values of standard fields are omitted from content
+		if field.IsZero() {
+			nullFields = append(nullFields, name)
+		}
+	}
+
+	if len(nullFields) == len(names) {
+		return nil, nullFields, nil
+	}
+
+	content := &WorkItem{WorkItem: *item.ToWS()}
+	if len(customFields) > 0 {
+		content.CustomFields = &ArrayOfCustom{Custom: customFields}
+	}
+	return content, nullFields, nil
+}
+
+// clears standard fields with SetFieldsNull and custom fields by setting them to nil
+func (p *Polarion) setFieldsNull(ctx context.Context, uri *tracker_ws.SubterraURI, fields []string) error {
+	var standardFields []string
+	for _, field := range fields {
+		key, ok := strings.CutPrefix(field, customFieldsPrefix)
+		if !ok {
+			standardFields = append(standardFields, field)
+			continue
+		}
+
+		if err := p.setCustomField(ctx, uri, key, nil); err != nil {
+			return fmt.Errorf("failed to clear custom field '%s' of work item %s: %v", key, *uri, err)
+		}
+	}
+
+	if len(standardFields) == 0 {
+		return nil
+	}
+
+	req := tracker_ws.SetFieldsNull{
+		WorkitemURI: uri,
+		Fields:      standardFields,
+	}
+	if _, err := p.TrackerWS.SetFieldsNullContext(ctx, &req); err != nil {
+		return fmt.Errorf("failed to clear fields %v of work item %s: %v", standardFields, *uri, err)
+	}
+	return nil
+}
+
+// updates work item with values set in content
+func (p *Polarion) updateWorkItem(ctx context.Context, uri *tracker_ws.SubterraURI, content *WorkItem) error {
+	content.Uri = uri
+	req := updateWorkItemRequest{
+		Content: content,
+	}
+	if err := p.TrackerClient.CallContext(ctx, "''", &req, &tracker_ws.UpdateWorkItemResponse{}); err != nil {
+		return fmt.Errorf("failed to update work item %s: %v", *uri, err)
+	}
+	return nil
+}
+
+// reads back updated timestamp of written work item,
+// result with URI only is returned with the error if it can not be read
+func (p *Polarion) writeResult(ctx context.Context, uri *tracker_ws.SubterraURI) (*WriteResult, error) {
+	req := tracker_ws.GetWorkItemByUriWithFields{
+		Uri:  uri,
+		Keys: []string{"updated"},
+	}
+	result := &WriteResult{URI: uri}
+	resp, err := p.TrackerWS.GetWorkItemByUriWithFieldsContext(ctx, &req)
+	if err != nil {
+		return result, fmt.Errorf("failed to get updated timestamp of work item %s: %v", *uri, err)
+	}
+
+	if resp.GetWorkItemByUriWithFieldsReturn != nil {
+		result.Updated = model.FromXSDDateTime(resp.GetWorkItemByUriWithFieldsReturn.Updated)
+	}
+	return result, nil
+}
+
+// untyped nil or nil pointer, map, slice or interface
+func isNilValue(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
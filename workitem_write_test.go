@@ -0,0 +1,84 @@
+package polarion_wsdl
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/AVaitkunas/polarion-wsdl/model"
+)
+
+func TestContentFromMask(t *testing.T) {
+	uri := model.NewURI("subterra:data-service:objects:/default/demo${WorkItem}DEMO-1")
+	var description *model.Text
+
+	tests := []struct {
+		name       string
+		changes    FieldMask
+		wantCustom map[string]*CustomValue
+		wantTitle  string
+		wantNull   []string
+	}{
+		{
+			name:       "zero number is written",
+			changes:    FieldMask{"customFields.count": 0},
+			wantCustom: map[string]*CustomValue{"count": {XSIType: "xsd:int", InnerXML: "0"}},
+		},
+		{
+			name:       "false is written",
+			changes:    FieldMask{"customFields.done": false},
+			wantCustom: map[string]*CustomValue{"done": {XSIType: "xsd:boolean", InnerXML: "false"}},
+		},
+		{
+			name:      "nil custom field is cleared",
+			changes:   FieldMask{"customFields.count": nil, "title": "Brakes"},
+			wantTitle: "Brakes",
+			wantNull:  []string{"customFields.count"},
+		},
+		{
+			name:     "nil pointer and empty standard field are cleared",
+			changes:  FieldMask{"description": description, "title": ""},
+			wantNull: []string{"description", "title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Polarion{}
+			p.customFieldKeys.add("demo", []string{"count", "done"}, true)
+
+			content, nullFields, err := p.contentFromMask(context.Background(), uri, tt.changes)
+			if err != nil {
+				t.Fatalf("contentFromMask() error: %v", err)
+			}
+			if !reflect.DeepEqual(nullFields, tt.wantNull) {
+				t.Errorf("null fields = %v, want %v", nullFields, tt.wantNull)
+			}
+
+			if tt.wantCustom == nil && tt.wantTitle == "" {
+				if content != nil {
+					t.Errorf("content = %+v, want nil", content)
+				}
+				return
+			}
+			if content == nil {
+				t.Fatal("content = nil")
+			}
+			if content.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", content.Title, tt.wantTitle)
+			}
+			custom := map[string]*CustomValue{}
+			if content.CustomFields != nil {
+				for _, field := range content.CustomFields.Custom {
+					custom[field.Key] = field.Value
+				}
+			}
+			if tt.wantCustom == nil {
+				tt.wantCustom = map[string]*CustomValue{}
+			}
+			if !reflect.DeepEqual(custom, tt.wantCustom) {
+				t.Errorf("custom fields = %v, want %v", custom, tt.wantCustom)
+			}
+		})
+	}
+}